@@ -0,0 +1,231 @@
+package bimg
+
+/*
+#cgo pkg-config: vips
+#include "vips/vips.h"
+*/
+import "C"
+
+import (
+	"fmt"
+	"math"
+	"runtime"
+	"sort"
+)
+
+// colorSampleSize is the maximum width and height of the
+// downsampled image used to extract the colours.
+const colorSampleSize = 100
+
+// DominantColors returns up to n representative colours of the image, sorted
+// by the number of pixels they represent, using median cut quantization on
+// a downsampled copy of the image. Fully transparent pixels are ignored and
+// embedded ICC profiles are honoured. Fully transparent images have no colours.
+func DominantColors(buf []byte, n int) ([]Color, error) {
+	defer C.vips_thread_shutdown()
+	defer runtime.KeepAlive(buf)
+
+	if n < 1 {
+		return nil, fmt.Errorf("invalid number of colors: %d", n)
+	}
+
+	pixels, err := colorPixels(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	return medianCut(pixels, n), nil
+}
+
+// AverageColor returns the average colour of the image, ignoring fully
+// transparent pixels and honouring embedded ICC profiles.
+// Fully transparent images return the zero Color.
+func AverageColor(buf []byte) (Color, error) {
+	defer C.vips_thread_shutdown()
+	defer runtime.KeepAlive(buf)
+
+	pixels, err := colorPixels(buf)
+	if err != nil {
+		return Color{}, err
+	}
+
+	return averageColor(pixels), nil
+}
+
+// colorPixels returns the sRGB colours of the visible pixels
+// of the image, scaled down to fit into colorSampleSize pixels.
+func colorPixels(buf []byte) ([]Color, error) {
+	image, err := loadSample(buf, colorSampleSize, colorSampleSize)
+	if err != nil {
+		return nil, err
+	}
+
+	if scale := math.Min(float64(colorSampleSize)/float64(image.Xsize), float64(colorSampleSize)/float64(image.Ysize)); scale < 1 {
+		image, err = vipsResize(image, scale, scale)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// The built-in sRGB profile needs libvips 8.8+ with lcms,
+	// the colour space conversion below is used otherwise
+	if vipsHasProfile(image) && vipsHasBuiltinICC() {
+		image, err = vipsTransformEmbeddedICC(image, ICCProfileSRGB)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	image, err = vipsColourspace(image, InterpretationSRGB)
+	if err != nil {
+		return nil, err
+	}
+
+	image, err = vipsCastUchar(image)
+	if err != nil {
+		return nil, err
+	}
+
+	if !vipsHasAlpha(image) {
+		image, err = vipsAddAlpha(image)
+		if err != nil {
+			return nil, err
+		}
+	}
+	defer C.g_object_unref(C.gpointer(image))
+
+	data, err := vipsImagePixels(image)
+	if err != nil {
+		return nil, err
+	}
+
+	bands := int(image.Bands)
+	pixels := make([]Color, 0, len(data)/bands)
+	for i := 0; i+bands <= len(data); i += bands {
+		if data[i+bands-1] == 0 {
+			continue
+		}
+		pixels = append(pixels, Color{data[i], data[i+1], data[i+2]})
+	}
+
+	return pixels, nil
+}
+
+func averageColor(pixels []Color) Color {
+	if len(pixels) == 0 {
+		return Color{}
+	}
+
+	var r, g, b int
+	for _, p := range pixels {
+		r += int(p.R)
+		g += int(p.G)
+		b += int(p.B)
+	}
+
+	n := len(pixels)
+	return Color{uint8((r + n/2) / n), uint8((g + n/2) / n), uint8((b + n/2) / n)}
+}
+
+// colorBox is a set of pixels of the median cut quantization.
+type colorBox struct {
+	pixels  []Color
+	channel int
+	spread  int
+}
+
+func newColorBox(pixels []Color) colorBox {
+	box := colorBox{pixels: pixels}
+
+	min := [3]int{255, 255, 255}
+	max := [3]int{}
+	for _, p := range pixels {
+		for c, v := range [3]int{int(p.R), int(p.G), int(p.B)} {
+			if v < min[c] {
+				min[c] = v
+			}
+			if v > max[c] {
+				max[c] = v
+			}
+		}
+	}
+
+	for c := range min {
+		if spread := max[c] - min[c]; spread > box.spread {
+			box.channel, box.spread = c, spread
+		}
+	}
+	return box
+}
+
+// medianCut splits the pixels in up to n boxes, cutting the box with the
+// largest population times spread at the median of its widest channel.
+func medianCut(pixels []Color, n int) []Color {
+	if len(pixels) == 0 {
+		return []Color{}
+	}
+
+	boxes := []colorBox{newColorBox(pixels)}
+	for len(boxes) < n {
+		index, score := -1, 0
+		for i, box := range boxes {
+			if s := len(box.pixels) * box.spread; len(box.pixels) > 1 && s > score {
+				index, score = i, s
+			}
+		}
+		if index < 0 {
+			break
+		}
+
+		box := boxes[index]
+		channel := box.channel
+		sort.Slice(box.pixels, func(i, j int) bool {
+			return colorChannel(box.pixels[i], channel) < colorChannel(box.pixels[j], channel)
+		})
+
+		median := len(box.pixels) / 2
+		boxes[index] = newColorBox(box.pixels[:median])
+		boxes = append(boxes, newColorBox(box.pixels[median:]))
+	}
+
+	sort.SliceStable(boxes, func(i, j int) bool {
+		return len(boxes[i].pixels) > len(boxes[j].pixels)
+	})
+
+	colors := make([]Color, len(boxes))
+	for i, box := range boxes {
+		colors[i] = averageColor(box.pixels)
+	}
+	return colors
+}
+
+func colorChannel(c Color, channel int) uint8 {
+	switch channel {
+	case 1:
+		return c.G
+	case 2:
+		return c.B
+	}
+	return c.R
+}
+
+// colorToLab converts the sRGB colour to CIELAB, using the D65 white point as libvips does.
+func colorToLab(c Color) (l, a, b float64) {
+	r := srgbToLinear(c.R)
+	g := srgbToLinear(c.G)
+	bl := srgbToLinear(c.B)
+
+	x := (0.4124*r + 0.3576*g + 0.1805*bl) / 0.95047
+	y := 0.2126*r + 0.7152*g + 0.0722*bl
+	z := (0.0193*r + 0.1192*g + 0.9505*bl) / 1.08883
+
+	fx, fy, fz := labF(x), labF(y), labF(z)
+	return 116*fy - 16, 500 * (fx - fy), 200 * (fy - fz)
+}
+
+func labF(t float64) float64 {
+	if t > 216.0/24389.0 {
+		return math.Cbrt(t)
+	}
+	return (24389.0/27.0*t + 16) / 116
+}
@@ -0,0 +1,105 @@
+package bimg
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDominantColors(t *testing.T) {
+	files := []string{"test.jpg", "test.png", "test.webp", "transparent.png", "test_icc_prophoto.jpg"}
+
+	for _, file := range files {
+		colors, err := DominantColors(readImage(file), 5)
+		if err != nil {
+			t.Fatalf("Cannot extract the colors of %s: %s", file, err)
+		}
+		if len(colors) == 0 || len(colors) > 5 {
+			t.Fatalf("Unexpected number of colors for %s: %d", file, len(colors))
+		}
+	}
+}
+
+func TestDominantColorsInvalid(t *testing.T) {
+	if _, err := DominantColors(readImage("test.jpg"), 0); err == nil {
+		t.Fatal("Expected error for an invalid number of colors")
+	}
+	if _, err := DominantColors([]byte{}, 5); err == nil {
+		t.Fatal("Expected error for an empty buffer")
+	}
+}
+
+func TestAverageColor(t *testing.T) {
+	color, err := AverageColor(readImage("test.jpg"))
+	if err != nil {
+		t.Fatalf("Cannot extract the average color: %s", err)
+	}
+	if color == (Color{}) {
+		t.Fatal("Unexpected black average color")
+	}
+}
+
+func TestMetadataColors(t *testing.T) {
+	metadata, err := MetadataWithOptions(readImage("test.jpg"), MetadataOptions{Colors: 3})
+	if err != nil {
+		t.Fatalf("Cannot read the metadata: %s", err)
+	}
+	if len(metadata.DominantColors) != 3 {
+		t.Fatalf("Unexpected number of colors: %d", len(metadata.DominantColors))
+	}
+
+	metadata, err = Metadata(readImage("test.jpg"))
+	if err != nil {
+		t.Fatalf("Cannot read the metadata: %s", err)
+	}
+	if metadata.DominantColors != nil {
+		t.Fatal("Colors must not be extracted by default")
+	}
+}
+
+func TestMedianCut(t *testing.T) {
+	red, blue := Color{255, 0, 0}, Color{0, 0, 255}
+
+	pixels := make([]Color, 0, 100)
+	for i := 0; i < 50; i++ {
+		pixels = append(pixels, red, blue)
+	}
+
+	colors := medianCut(pixels, 4)
+	if len(colors) != 2 {
+		t.Fatalf("Unexpected number of colors: %v", colors)
+	}
+	if !(colors[0] == red && colors[1] == blue) && !(colors[0] == blue && colors[1] == red) {
+		t.Fatalf("Unexpected colors: %v", colors)
+	}
+
+	if colors := medianCut(nil, 4); len(colors) != 0 {
+		t.Fatalf("Unexpected colors for no pixels: %v", colors)
+	}
+}
+
+func TestAverageColorPixels(t *testing.T) {
+	pixels := []Color{{0, 0, 0}, {255, 255, 255}, {0, 100, 200}, {255, 101, 0}}
+
+	if color := averageColor(pixels); color != (Color{128, 114, 114}) {
+		t.Fatalf("Unexpected average color: %v", color)
+	}
+}
+
+func TestColorToLab(t *testing.T) {
+	tests := []struct {
+		color   Color
+		l, a, b float64
+	}{
+		{Color{255, 255, 255}, 100, 0, 0},
+		{Color{0, 0, 0}, 0, 0, 0},
+		{Color{255, 0, 0}, 53.24, 80.09, 67.20},
+		{Color{0, 0, 255}, 32.30, 79.19, -107.86},
+	}
+
+	for _, test := range tests {
+		l, a, b := colorToLab(test.color)
+		if math.Abs(l-test.l) > 0.1 || math.Abs(a-test.a) > 0.1 || math.Abs(b-test.b) > 0.1 {
+			t.Fatalf("Unexpected Lab for %v: %.2f %.2f %.2f", test.color, l, a, b)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package bimg
+
+/*
+#cgo pkg-config: vips
+#include "vips/vips.h"
+*/
+import "C"
+
+import (
+	"errors"
+	"math"
+	"runtime"
+)
+
+var (
+	// ErrCompareSizeMismatch is returned when comparing images of different dimensions.
+	ErrCompareSizeMismatch = errors.New("images dimensions do not match")
+)
+
+// CompareOptions represents the supported image comparison options.
+type CompareOptions struct {
+	// Resize scales the second image to the dimensions of the first one,
+	// instead of failing when they differ.
+	Resize bool
+	// Diff enables the generation of an image highlighting the changed pixels.
+	Diff bool
+	// DiffType defines the type of the diff image. Defaults to PNG.
+	DiffType ImageType
+}
+
+// CompareResult represents the similarity metrics between two images.
+// Transparent images are compared as flattened over a white background.
+type CompareResult struct {
+	// PSNR is the peak signal-to-noise ratio in dB, +Inf for identical images.
+	PSNR float64
+	// SSIM is the structural similarity of the images luminance, between 0 and 1.
+	SSIM float64
+	// MaxDiff is the maximum absolute difference of any pixel band, between 0 and 255.
+	MaxDiff float64
+	// Diff is the image highlighting the changed pixels in red, if requested.
+	Diff []byte
+}
+
+// Compare compares two images, returning their similarity metrics.
+func Compare(a, b []byte) (CompareResult, error) {
+	return CompareWithOptions(a, b, CompareOptions{})
+}
+
+// CompareWithOptions compares two images based on the given options,
+// returning their similarity metrics.
+func CompareWithOptions(a, b []byte, o CompareOptions) (CompareResult, error) {
+	defer C.vips_thread_shutdown()
+	defer runtime.KeepAlive(a)
+	defer runtime.KeepAlive(b)
+
+	imageA, err := loadComparableImage(a)
+	if err != nil {
+		return CompareResult{}, err
+	}
+	defer C.g_object_unref(C.gpointer(imageA))
+
+	imageB, err := loadComparableImage(b)
+	if err != nil {
+		return CompareResult{}, err
+	}
+
+	if imageA.Xsize != imageB.Xsize || imageA.Ysize != imageB.Ysize {
+		if !o.Resize {
+			C.g_object_unref(C.gpointer(imageB))
+			return CompareResult{}, ErrCompareSizeMismatch
+		}
+		imageB, err = resizeImageExact(imageB, int(imageA.Xsize), int(imageA.Ysize))
+		if err != nil {
+			return CompareResult{}, err
+		}
+	}
+	defer C.g_object_unref(C.gpointer(imageB))
+
+	mse, maxDiff, err := vipsCompare(imageA, imageB)
+	if err != nil {
+		return CompareResult{}, err
+	}
+
+	ssim, err := vipsSSIM(imageA, imageB)
+	if err != nil {
+		return CompareResult{}, err
+	}
+
+	result := CompareResult{
+		PSNR:    psnr(mse),
+		SSIM:    ssim,
+		MaxDiff: maxDiff,
+	}
+
+	if o.Diff {
+		result.Diff, err = compareDiff(imageA, imageB, o)
+		if err != nil {
+			return CompareResult{}, err
+		}
+	}
+
+	return result, nil
+}
+
+func loadComparableImage(buf []byte) (*C.VipsImage, error) {
+	image, _, err := loadImage(buf)
+	if err != nil {
+		return nil, err
+	}
+	return vipsComparePrepare(image)
+}
+
+func compareDiff(a, b *C.VipsImage, o CompareOptions) ([]byte, error) {
+	if o.DiffType == UNKNOWN {
+		o.DiffType = PNG
+	}
+
+	diff, err := vipsDiffImage(a, b)
+	if err != nil {
+		return nil, err
+	}
+
+	return vipsSave(diff, vipsSaveOptions{
+		Type:           o.DiffType,
+		Quality:        Quality,
+		Compression:    6,
+		Interpretation: InterpretationSRGB,
+	})
+}
+
+// psnr returns the peak signal-to-noise ratio of 8-bit
+// images for the given mean squared error.
+func psnr(mse float64) float64 {
+	if mse == 0 {
+		return math.Inf(1)
+	}
+	return 10 * math.Log10(255*255/mse)
+}
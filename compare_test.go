@@ -0,0 +1,95 @@
+package bimg
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCompareIdentical(t *testing.T) {
+	buf, _ := Read("testdata/test.jpg")
+
+	result, err := Compare(buf, buf)
+	if err != nil {
+		t.Fatalf("Cannot compare the images: %s", err)
+	}
+
+	if !math.IsInf(result.PSNR, 1) {
+		t.Fatalf("Unexpected PSNR for identical images: %f", result.PSNR)
+	}
+	if result.SSIM < 0.999 {
+		t.Fatalf("Unexpected SSIM for identical images: %f", result.SSIM)
+	}
+	if result.MaxDiff != 0 {
+		t.Fatalf("Unexpected max difference for identical images: %f", result.MaxDiff)
+	}
+}
+
+func TestCompareDifferent(t *testing.T) {
+	buf, _ := Read("testdata/test.jpg")
+	blurred, err := Resize(buf, Options{GaussianBlur: GaussianBlur{Sigma: 5}})
+	if err != nil {
+		t.Fatalf("Cannot process the image: %s", err)
+	}
+
+	result, err := CompareWithOptions(buf, blurred, CompareOptions{Diff: true})
+	if err != nil {
+		t.Fatalf("Cannot compare the images: %s", err)
+	}
+
+	if math.IsInf(result.PSNR, 1) || result.PSNR <= 0 {
+		t.Fatalf("Unexpected PSNR: %f", result.PSNR)
+	}
+	if result.SSIM >= 1 || result.SSIM <= 0 {
+		t.Fatalf("Unexpected SSIM: %f", result.SSIM)
+	}
+	if result.MaxDiff == 0 {
+		t.Fatal("Unexpected max difference: 0")
+	}
+	if DetermineImageType(result.Diff) != PNG {
+		t.Fatal("Diff image is not png")
+	}
+
+	err = assertSize(result.Diff, 1680, 1050)
+	if err != nil {
+		t.Error(err)
+	}
+
+	Write("testdata/test_compare_diff_out.png", result.Diff)
+}
+
+func TestCompareSizeMismatch(t *testing.T) {
+	buf, _ := Read("testdata/test.jpg")
+	small, err := Resize(buf, Options{Width: 840, Height: 525})
+	if err != nil {
+		t.Fatalf("Cannot process the image: %s", err)
+	}
+
+	_, err = Compare(buf, small)
+	if err != ErrCompareSizeMismatch {
+		t.Fatalf("Expected ErrCompareSizeMismatch, got: %v", err)
+	}
+
+	result, err := CompareWithOptions(buf, small, CompareOptions{Resize: true})
+	if err != nil {
+		t.Fatalf("Cannot compare the images: %s", err)
+	}
+	if result.SSIM < 0.8 {
+		t.Fatalf("Unexpected SSIM for resized image: %f", result.SSIM)
+	}
+}
+
+func TestCompareTransparent(t *testing.T) {
+	buf, _ := Read("testdata/transparent.png")
+	flattened, err := Resize(buf, Options{Type: JPEG, Background: Color{255, 255, 255}, Quality: 100})
+	if err != nil {
+		t.Fatalf("Cannot process the image: %s", err)
+	}
+
+	result, err := Compare(buf, flattened)
+	if err != nil {
+		t.Fatalf("Cannot compare the images: %s", err)
+	}
+	if result.SSIM < 0.9 {
+		t.Fatalf("Unexpected SSIM for flattened image: %f", result.SSIM)
+	}
+}
@@ -0,0 +1,188 @@
+package bimg
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrNoGPS is returned when the image has no GPS position.
+	ErrNoGPS = errors.New("image has no GPS position")
+	// ErrNoDateTimeOriginal is returned when the image has no original date and time.
+	ErrNoDateTimeOriginal = errors.New("image has no original date and time")
+)
+
+// exifTimeLayout is the EXIF date and time format.
+const exifTimeLayout = "2006:01:02 15:04:05"
+
+// GPSPosition represents the decimal GPS position of the image.
+type GPSPosition struct {
+	// Latitude and Longitude are expressed in degrees,
+	// negative in the southern and western hemispheres.
+	Latitude  float64
+	Longitude float64
+	// Altitude is expressed in meters, negative below the sea level.
+	Altitude float64
+}
+
+// ParseRational parses an EXIF rational such as "1/125" or "-1/3", or a plain number.
+func ParseRational(s string) (float64, error) {
+	s = strings.TrimSpace(s)
+	i := strings.Index(s, "/")
+	if i < 0 {
+		return strconv.ParseFloat(s, 64)
+	}
+
+	num, err := strconv.ParseFloat(s[:i], 64)
+	if err != nil {
+		return 0, err
+	}
+	den, err := strconv.ParseFloat(s[i+1:], 64)
+	if err != nil {
+		return 0, err
+	}
+	if den == 0 {
+		return 0, fmt.Errorf("invalid rational: %s", s)
+	}
+	return num / den, nil
+}
+
+// parseCoordinate parses the "degrees minutes seconds" rationals of a GPS coordinate.
+func parseCoordinate(s, ref string) (float64, error) {
+	parts := strings.Fields(s)
+	if len(parts) == 0 || len(parts) > 3 {
+		return 0, fmt.Errorf("invalid GPS coordinate: %s", s)
+	}
+
+	var coordinate float64
+	for i, part := range parts {
+		value, err := ParseRational(part)
+		if err != nil {
+			return 0, err
+		}
+		coordinate += value / [3]float64{1, 60, 3600}[i]
+	}
+
+	if ref == "S" || ref == "W" {
+		coordinate = -coordinate
+	}
+	return coordinate, nil
+}
+
+// GPS returns the decimal GPS position of the image.
+func (e EXIF) GPS() (GPSPosition, error) {
+	if e.GPSLatitude == "" || e.GPSLongitude == "" {
+		return GPSPosition{}, ErrNoGPS
+	}
+
+	latitude, err := parseCoordinate(e.GPSLatitude, e.GPSLatitudeRef)
+	if err != nil {
+		return GPSPosition{}, err
+	}
+	longitude, err := parseCoordinate(e.GPSLongitude, e.GPSLongitudeRef)
+	if err != nil {
+		return GPSPosition{}, err
+	}
+
+	position := GPSPosition{Latitude: latitude, Longitude: longitude}
+	if e.GPSAltitude != "" {
+		altitude, err := ParseRational(e.GPSAltitude)
+		if err != nil {
+			return GPSPosition{}, err
+		}
+		// libexif formats the below sea level reference as "Sea level reference"
+		if e.GPSAltitudeRef != "" && e.GPSAltitudeRef != "Sea level" && e.GPSAltitudeRef != "0" {
+			altitude = -altitude
+		}
+		position.Altitude = altitude
+	}
+
+	return position, nil
+}
+
+// OriginalTime returns the date and time when the image was taken, including
+// SubSecTimeOriginal. Its location is the OffsetTimeOriginal zone, or UTC
+// if the offset is unknown.
+func (e EXIF) OriginalTime() (time.Time, error) {
+	if e.DateTimeOriginal == "" {
+		return time.Time{}, ErrNoDateTimeOriginal
+	}
+
+	location := time.UTC
+	if e.OffsetTimeOriginal != "" {
+		offset, err := time.Parse("-07:00", e.OffsetTimeOriginal)
+		if err != nil {
+			return time.Time{}, err
+		}
+		_, seconds := offset.Zone()
+		location = time.FixedZone(e.OffsetTimeOriginal, seconds)
+	}
+
+	t, err := time.ParseInLocation(exifTimeLayout, e.DateTimeOriginal, location)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if subsec := strings.TrimSpace(e.SubSecTimeOriginal); subsec != "" {
+		fraction, err := strconv.ParseFloat("0."+subsec, 64)
+		if err != nil {
+			return time.Time{}, err
+		}
+		t = t.Add(time.Duration(fraction * float64(time.Second)))
+	}
+
+	return t, nil
+}
+
+// exifFloat returns the value of the EXIF rational, or 0 if absent or malformed.
+func exifFloat(s string) float64 {
+	value, err := ParseRational(s)
+	if err != nil {
+		return 0
+	}
+	return value
+}
+
+// ExposureTimeSeconds returns the exposure time in seconds.
+func (e EXIF) ExposureTimeSeconds() float64 {
+	return exifFloat(e.ExposureTime)
+}
+
+// FNumberValue returns the F number.
+func (e EXIF) FNumberValue() float64 {
+	return exifFloat(e.FNumber)
+}
+
+// FocalLengthMillimeters returns the lens focal length in millimeters.
+func (e EXIF) FocalLengthMillimeters() float64 {
+	return exifFloat(e.FocalLength)
+}
+
+// ApertureAPEX returns the lens aperture in APEX units.
+func (e EXIF) ApertureAPEX() float64 {
+	return exifFloat(e.ApertureValue)
+}
+
+// ShutterSpeedAPEX returns the shutter speed in APEX units.
+func (e EXIF) ShutterSpeedAPEX() float64 {
+	return exifFloat(e.ShutterSpeedValue)
+}
+
+// BrightnessAPEX returns the brightness in APEX units.
+func (e EXIF) BrightnessAPEX() float64 {
+	return exifFloat(e.BrightnessValue)
+}
+
+// ExposureBiasEV returns the exposure bias in EV.
+func (e EXIF) ExposureBiasEV() float64 {
+	return exifFloat(e.ExposureBiasValue)
+}
+
+// Resolution returns the horizontal and vertical resolution,
+// in pixels per ResolutionUnit.
+func (e EXIF) Resolution() (float64, float64) {
+	return exifFloat(e.XResolution), exifFloat(e.YResolution)
+}
@@ -0,0 +1,126 @@
+package bimg
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestParseRational(t *testing.T) {
+	values := []struct {
+		value    string
+		expected float64
+	}{
+		{"72/1", 72},
+		{"1/125", 0.008},
+		{"-1/3", -1.0 / 3},
+		{"12/5", 2.4},
+		{"2.5", 2.5},
+	}
+
+	for _, v := range values {
+		value, err := ParseRational(v.value)
+		if err != nil {
+			t.Fatalf("Cannot parse the rational %s: %s", v.value, err)
+		}
+		if math.Abs(value-v.expected) > 1e-9 {
+			t.Fatalf("Unexpected rational %s: %f != %f", v.value, value, v.expected)
+		}
+	}
+
+	for _, value := range []string{"", "1/0", "a/b"} {
+		if _, err := ParseRational(value); err == nil {
+			t.Fatalf("Expected an error parsing %q", value)
+		}
+	}
+}
+
+func TestEXIFGPS(t *testing.T) {
+	exif := EXIF{
+		GPSLatitudeRef:  "S",
+		GPSLatitude:     "55/1 43/1 5287/100",
+		GPSLongitudeRef: "E",
+		GPSLongitude:    "37/1 35/1 5571/100",
+		GPSAltitudeRef:  "Sea level",
+		GPSAltitude:     "90514/693",
+	}
+
+	position, err := exif.GPS()
+	if err != nil {
+		t.Fatalf("Cannot parse the GPS position: %s", err)
+	}
+	if math.Abs(position.Latitude+55.731353) > 1e-6 {
+		t.Fatalf("Unexpected latitude: %f", position.Latitude)
+	}
+	if math.Abs(position.Longitude-37.598808) > 1e-6 {
+		t.Fatalf("Unexpected longitude: %f", position.Longitude)
+	}
+	if math.Abs(position.Altitude-130.6118) > 1e-4 {
+		t.Fatalf("Unexpected altitude: %f", position.Altitude)
+	}
+
+	exif.GPSAltitudeRef = "Sea level reference"
+	position, _ = exif.GPS()
+	if position.Altitude >= 0 {
+		t.Fatalf("Expected an altitude below the sea level: %f", position.Altitude)
+	}
+
+	if _, err := (EXIF{}).GPS(); err != ErrNoGPS {
+		t.Fatalf("Expected ErrNoGPS, got %v", err)
+	}
+}
+
+func TestEXIFOriginalTime(t *testing.T) {
+	exif := EXIF{
+		DateTimeOriginal:   "2020:07:28 19:18:49",
+		SubSecTimeOriginal: "25",
+		OffsetTimeOriginal: "+03:00",
+	}
+
+	originalTime, err := exif.OriginalTime()
+	if err != nil {
+		t.Fatalf("Cannot parse the original time: %s", err)
+	}
+	expected := time.Date(2020, 7, 28, 16, 18, 49, 250000000, time.UTC)
+	if !originalTime.Equal(expected) {
+		t.Fatalf("Unexpected original time: %s != %s", originalTime, expected)
+	}
+
+	exif.OffsetTimeOriginal = ""
+	originalTime, _ = exif.OriginalTime()
+	if originalTime.Location() != time.UTC || originalTime.Hour() != 19 {
+		t.Fatalf("Unexpected original time without offset: %s", originalTime)
+	}
+
+	if _, err := (EXIF{}).OriginalTime(); err != ErrNoDateTimeOriginal {
+		t.Fatalf("Expected ErrNoDateTimeOriginal, got %v", err)
+	}
+}
+
+func TestEXIFValues(t *testing.T) {
+	if VipsMajorVersion <= 8 && VipsMinorVersion < 10 {
+		t.Skip("Skip test in libvips < 8.10")
+		return
+	}
+
+	metadata, err := Metadata(readFile("test_exif_full.jpg"))
+	if err != nil {
+		t.Fatalf("Cannot read the image: %s", err)
+	}
+
+	position, err := metadata.EXIF.GPS()
+	if err != nil {
+		t.Fatalf("Cannot parse the GPS position: %s", err)
+	}
+	if position.Latitude < 55 || position.Latitude > 56 || position.Longitude < 37 || position.Longitude > 38 {
+		t.Fatalf("Unexpected GPS position: %#v", position)
+	}
+
+	if _, err := metadata.EXIF.OriginalTime(); err != nil {
+		t.Fatalf("Cannot parse the original time: %s", err)
+	}
+
+	if metadata.EXIF.FNumberValue() <= 0 || metadata.EXIF.ExposureTimeSeconds() <= 0 {
+		t.Fatalf("Unexpected exposure values: %s %s", metadata.EXIF.FNumber, metadata.EXIF.ExposureTime)
+	}
+}
@@ -0,0 +1,193 @@
+package bimg
+
+/*
+#cgo pkg-config: vips
+#include "vips/vips.h"
+*/
+import "C"
+
+import (
+	"fmt"
+	"math"
+	"runtime"
+	"sort"
+)
+
+// HashAlgo represents the perceptual hash algorithm.
+type HashAlgo int
+
+const (
+	// AHash represents the average hash algorithm, comparing every
+	// pixel of an 8x8 greyscale thumbnail with the mean value.
+	AHash HashAlgo = iota
+	// DHash represents the difference hash algorithm, comparing the
+	// adjacent pixels of a 9x8 greyscale thumbnail.
+	DHash
+	// PHash represents the DCT based perceptual hash algorithm, comparing
+	// the low frequencies of a 32x32 greyscale thumbnail with their median.
+	PHash
+)
+
+var hashAlgos = map[HashAlgo]string{
+	AHash: "ahash",
+	DHash: "dhash",
+	PHash: "phash",
+}
+
+func (a HashAlgo) String() string {
+	return hashAlgos[a]
+}
+
+// PerceptualHash returns the 64 bits perceptual hash of the image, using the
+// given algorithm. Similar images produce hashes with a small Hamming distance.
+func PerceptualHash(buf []byte, algo HashAlgo) (uint64, error) {
+	defer C.vips_thread_shutdown()
+	defer runtime.KeepAlive(buf)
+
+	var width, height int
+	switch algo {
+	case AHash:
+		width, height = 8, 8
+	case DHash:
+		width, height = 9, 8
+	case PHash:
+		width, height = 32, 32
+	default:
+		return 0, fmt.Errorf("unsupported hash algorithm: %d", algo)
+	}
+
+	pixels, err := greyscalePixels(buf, width, height)
+	if err != nil {
+		return 0, err
+	}
+
+	switch algo {
+	case DHash:
+		return differenceHash(pixels, width, height), nil
+	case PHash:
+		return dctHash(pixels, width), nil
+	}
+	return averageHash(pixels), nil
+}
+
+// HammingDistance returns the number of bits which differ between two hashes.
+func HammingDistance(a, b uint64) int {
+	distance := 0
+	for x := a ^ b; x != 0; x &= x - 1 {
+		distance++
+	}
+	return distance
+}
+
+// greyscalePixels returns the luminance of the image scaled
+// to exactly width x height pixels.
+func greyscalePixels(buf []byte, width, height int) ([]byte, error) {
+	image, err := loadSample(buf, width, height)
+	if err != nil {
+		return nil, err
+	}
+
+	image, err = vipsFlattenBackground(image, Color{255, 255, 255})
+	if err != nil {
+		return nil, err
+	}
+
+	image, err = vipsColourspace(image, InterpretationBW)
+	if err != nil {
+		return nil, err
+	}
+
+	image, err = vipsCastUchar(image)
+	if err != nil {
+		return nil, err
+	}
+
+	image, err = resizeImageExact(image, width, height)
+	if err != nil {
+		return nil, err
+	}
+	defer C.g_object_unref(C.gpointer(image))
+
+	return vipsImagePixels(image)
+}
+
+func averageHash(pixels []byte) uint64 {
+	sum := 0
+	for _, p := range pixels {
+		sum += int(p)
+	}
+	mean := float64(sum) / float64(len(pixels))
+
+	var hash uint64
+	for _, p := range pixels {
+		hash <<= 1
+		if float64(p) > mean {
+			hash |= 1
+		}
+	}
+	return hash
+}
+
+func differenceHash(pixels []byte, width, height int) uint64 {
+	var hash uint64
+	for y := 0; y < height; y++ {
+		for x := 0; x < width-1; x++ {
+			hash <<= 1
+			if pixels[y*width+x] > pixels[y*width+x+1] {
+				hash |= 1
+			}
+		}
+	}
+	return hash
+}
+
+// dctHash hashes the 8x8 lowest frequencies of the DCT of
+// the size x size pixels against their median.
+func dctHash(pixels []byte, size int) uint64 {
+	const n = 8
+
+	// Cosine table for the n lowest frequencies
+	cosines := make([]float64, n*size)
+	for k := 0; k < n; k++ {
+		for i := 0; i < size; i++ {
+			cosines[k*size+i] = math.Cos(math.Pi / float64(size) * (float64(i) + 0.5) * float64(k))
+		}
+	}
+
+	// Separable DCT-II, rows first
+	rows := make([]float64, size*n)
+	for y := 0; y < size; y++ {
+		for k := 0; k < n; k++ {
+			sum := 0.0
+			for x := 0; x < size; x++ {
+				sum += float64(pixels[y*size+x]) * cosines[k*size+x]
+			}
+			rows[y*n+k] = sum
+		}
+	}
+
+	coefficients := make([]float64, n*n)
+	for k := 0; k < n; k++ {
+		for u := 0; u < n; u++ {
+			sum := 0.0
+			for y := 0; y < size; y++ {
+				sum += rows[y*n+u] * cosines[k*size+y]
+			}
+			coefficients[k*n+u] = sum
+		}
+	}
+
+	sorted := make([]float64, len(coefficients))
+	copy(sorted, coefficients)
+	sort.Float64s(sorted)
+	median := (sorted[len(sorted)/2-1] + sorted[len(sorted)/2]) / 2
+
+	var hash uint64
+	for _, c := range coefficients {
+		hash <<= 1
+		if c > median {
+			hash |= 1
+		}
+	}
+	return hash
+}
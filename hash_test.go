@@ -0,0 +1,100 @@
+package bimg
+
+import (
+	"testing"
+)
+
+func TestPerceptualHash(t *testing.T) {
+	buf, _ := Read("testdata/test.jpg")
+	resized, err := Resize(buf, Options{Width: 400, Height: 250, Quality: 60})
+	if err != nil {
+		t.Fatalf("Cannot process the image: %s", err)
+	}
+	other, _ := Read("testdata/northern_cardinal_bird.jpg")
+
+	for _, algo := range []HashAlgo{AHash, DHash, PHash} {
+		hash, err := PerceptualHash(buf, algo)
+		if err != nil {
+			t.Fatalf("Cannot hash the image with %s: %s", algo, err)
+		}
+
+		resizedHash, err := PerceptualHash(resized, algo)
+		if err != nil {
+			t.Fatalf("Cannot hash the resized image with %s: %s", algo, err)
+		}
+
+		otherHash, err := PerceptualHash(other, algo)
+		if err != nil {
+			t.Fatalf("Cannot hash the other image with %s: %s", algo, err)
+		}
+
+		if distance := HammingDistance(hash, resizedHash); distance > 5 {
+			t.Errorf("Unexpected %s distance for the resized image: %d", algo, distance)
+		}
+		if distance := HammingDistance(hash, otherHash); distance <= 5 {
+			t.Errorf("Unexpected %s distance for a different image: %d", algo, distance)
+		}
+	}
+}
+
+func TestPerceptualHashInvalid(t *testing.T) {
+	buf, _ := Read("testdata/test.jpg")
+
+	if _, err := PerceptualHash(buf, HashAlgo(10)); err == nil {
+		t.Fatal("Expected error for an unsupported algorithm")
+	}
+	if _, err := PerceptualHash([]byte{}, AHash); err == nil {
+		t.Fatal("Expected error for an empty buffer")
+	}
+}
+
+func TestHammingDistance(t *testing.T) {
+	tt := []struct {
+		a, b     uint64
+		expected int
+	}{
+		{0, 0, 0},
+		{0, 1, 1},
+		{0xFF, 0x0F, 4},
+		{0, 0xFFFFFFFFFFFFFFFF, 64},
+	}
+
+	for _, tc := range tt {
+		if got := HammingDistance(tc.a, tc.b); got != tc.expected {
+			t.Fatalf("HammingDistance(%x, %x): expected %d; got %d", tc.a, tc.b, tc.expected, got)
+		}
+	}
+}
+
+func TestAverageHash(t *testing.T) {
+	pixels := make([]byte, 64)
+	for i := 32; i < 64; i++ {
+		pixels[i] = 255
+	}
+
+	if hash := averageHash(pixels); hash != 0x00000000FFFFFFFF {
+		t.Fatalf("Unexpected hash: %x", hash)
+	}
+}
+
+func TestDifferenceHash(t *testing.T) {
+	// Every row gets darker from left to right
+	pixels := make([]byte, 72)
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 9; x++ {
+			pixels[y*9+x] = byte(255 - x*20)
+		}
+	}
+
+	if hash := differenceHash(pixels, 9, 8); hash != 0xFFFFFFFFFFFFFFFF {
+		t.Fatalf("Unexpected hash: %x", hash)
+	}
+}
+
+func BenchmarkPerceptualHashJpeg(b *testing.B) {
+	buf, _ := Read("testdata/test.jpg")
+
+	for n := 0; n < b.N; n++ {
+		PerceptualHash(buf, PHash)
+	}
+}
@@ -0,0 +1,108 @@
+package bimg
+
+/*
+#cgo pkg-config: vips
+#include "vips/vips.h"
+*/
+import "C"
+
+import (
+	"io/ioutil"
+	"os"
+	"runtime"
+)
+
+// Built-in ICC profiles shipped with libvips, usable as InputICC and OutputICC.
+const (
+	// ICCProfileSRGB is the sRGB profile.
+	ICCProfileSRGB = "srgb"
+	// ICCProfileP3 is the Display P3 profile (libvips 8.13+).
+	ICCProfileP3 = "p3"
+	// ICCProfileCMYK is the default CMYK profile.
+	ICCProfileCMYK = "cmyk"
+)
+
+// BuiltinICCProfile returns the bytes of the given built-in ICC profile,
+// such as ICCProfileSRGB (libvips 8.8+).
+func BuiltinICCProfile(name string) ([]byte, error) {
+	defer C.vips_thread_shutdown()
+	return vipsProfileLoad(name)
+}
+
+// ICCProfile returns the ICC profile embedded in the image, or an empty
+// slice if the image has none.
+func ICCProfile(buf []byte) ([]byte, error) {
+	defer C.vips_thread_shutdown()
+	defer runtime.KeepAlive(buf)
+
+	image, _, err := vipsReadHeader(buf)
+	if err != nil {
+		return nil, err
+	}
+	defer C.g_object_unref(C.gpointer(image))
+
+	return vipsImageBlob(image, iccField), nil
+}
+
+// setICCProfiles points the save options to files holding the ICC profiles
+// given as bytes, which libvips can only load by name or path.
+// The returned function releases the files once the image is saved.
+func setICCProfiles(saveOptions *vipsSaveOptions, o Options) (func(), error) {
+	var releases []func()
+	release := func() {
+		for _, r := range releases {
+			r()
+		}
+	}
+
+	profiles := []struct {
+		profile []byte
+		path    *string
+	}{
+		{o.InputICCProfile, &saveOptions.InputICC},
+		{o.OutputICCProfile, &saveOptions.OutputICC},
+	}
+	for _, p := range profiles {
+		if len(p.profile) == 0 {
+			continue
+		}
+		path, r, err := iccProfileFile(p.profile)
+		if err != nil {
+			release()
+			return nil, err
+		}
+		releases = append(releases, r)
+		*p.path = path
+	}
+
+	return release, nil
+}
+
+// iccProfileFile stores the ICC profile in an anonymous memory file when
+// supported by the platform, so that no temporary file is written,
+// or in a temporary file otherwise.
+func iccProfileFile(profile []byte) (string, func(), error) {
+	if path, release, err := memoryFile(profile); err == nil {
+		return path, release, nil
+	}
+	return tempFile(profile)
+}
+
+func tempFile(data []byte) (string, func(), error) {
+	file, err := ioutil.TempFile("", "bimg-*.icc")
+	if err != nil {
+		return "", nil, err
+	}
+	name := file.Name()
+
+	_, err = file.Write(data)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(name)
+		return "", nil, err
+	}
+
+	return name, func() { os.Remove(name) }, nil
+}
@@ -0,0 +1,123 @@
+//go:build linux
+// +build linux
+
+package bimg
+
+/*
+#cgo pkg-config: vips
+#include <stdlib.h>
+#include <sys/syscall.h>
+#include <unistd.h>
+#include "vips/vips.h"
+
+#ifndef MFD_CLOEXEC
+#define MFD_CLOEXEC 0x0001U
+#endif
+
+static int
+memfd_create_bridge(const char *name) {
+#ifdef SYS_memfd_create
+	return syscall(SYS_memfd_create, name, MFD_CLOEXEC);
+#else
+	return -1;
+#endif
+}
+*/
+import "C"
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"unsafe"
+)
+
+// maxIdleMemoryFiles is the number of unused memory files kept open,
+// so that the most recent profiles are not written again.
+const maxIdleMemoryFiles = 16
+
+type memoryFileEntry struct {
+	key  [sha256.Size]byte
+	file *os.File
+	refs int
+	idle *list.Element
+}
+
+// memoryFiles holds the memory files by the hash of their content,
+// the unused ones in least recently used order.
+var memoryFiles = struct {
+	sync.Mutex
+	entries map[[sha256.Size]byte]*memoryFileEntry
+	idle    *list.List
+}{entries: map[[sha256.Size]byte]*memoryFileEntry{}, idle: list.New()}
+
+// memoryFile writes the data into an anonymous memory file, which libvips
+// can open through its /proc/self/fd path. libvips caches the profiles by
+// path, so the files are shared by content: a path never points to other
+// data while libvips may remember it.
+func memoryFile(data []byte) (string, func(), error) {
+	key := sha256.Sum256(data)
+
+	memoryFiles.Lock()
+	defer memoryFiles.Unlock()
+
+	entry, ok := memoryFiles.entries[key]
+	if !ok {
+		name := C.CString("bimg")
+		defer C.free(unsafe.Pointer(name))
+
+		fd := int(C.memfd_create_bridge(name))
+		if fd < 0 {
+			return "", nil, errors.New("memfd_create is not supported")
+		}
+		file := os.NewFile(uintptr(fd), "bimg")
+
+		if _, err := file.Write(data); err != nil {
+			file.Close()
+			return "", nil, err
+		}
+		entry = &memoryFileEntry{key: key, file: file}
+		memoryFiles.entries[key] = entry
+	}
+
+	if entry.idle != nil {
+		memoryFiles.idle.Remove(entry.idle)
+		entry.idle = nil
+	}
+	entry.refs++
+
+	var once sync.Once
+	release := func() {
+		once.Do(func() { releaseMemoryFile(entry) })
+	}
+	return fmt.Sprintf("/proc/self/fd/%d", entry.file.Fd()), release, nil
+}
+
+// releaseMemoryFile marks the memory file as unused, closing the least
+// recently used ones beyond maxIdleMemoryFiles.
+func releaseMemoryFile(entry *memoryFileEntry) {
+	memoryFiles.Lock()
+	defer memoryFiles.Unlock()
+
+	entry.refs--
+	if entry.refs > 0 {
+		return
+	}
+	entry.idle = memoryFiles.idle.PushBack(entry)
+
+	closed := false
+	for memoryFiles.idle.Len() > maxIdleMemoryFiles {
+		oldest := memoryFiles.idle.Remove(memoryFiles.idle.Front()).(*memoryFileEntry)
+		delete(memoryFiles.entries, oldest.key)
+		oldest.file.Close()
+		closed = true
+	}
+
+	// The closed paths may be reused by other profiles
+	if closed {
+		C.vips_cache_drop_all()
+	}
+}
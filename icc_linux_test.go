@@ -0,0 +1,33 @@
+package bimg
+
+import (
+	"fmt"
+	"io/ioutil"
+	"testing"
+)
+
+func TestMemoryFileLimit(t *testing.T) {
+	openFiles := func() int {
+		files, err := ioutil.ReadDir("/proc/self/fd")
+		if err != nil {
+			t.Fatalf("Cannot list the open files: %s", err)
+		}
+		return len(files)
+	}
+
+	before := openFiles()
+	for i := 0; i < 10*maxIdleMemoryFiles; i++ {
+		path, release, err := memoryFile([]byte(fmt.Sprintf("profile %d", i)))
+		if err != nil {
+			t.Fatalf("Cannot write the profile: %s", err)
+		}
+		if _, err := ioutil.ReadFile(path); err != nil {
+			t.Fatalf("Cannot read the profile: %s", err)
+		}
+		release()
+	}
+
+	if after := openFiles(); after > before+maxIdleMemoryFiles {
+		t.Fatalf("Too many open files: %d > %d", after, before+maxIdleMemoryFiles)
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+// +build !linux
+
+package bimg
+
+import (
+	"errors"
+)
+
+// memoryFile is only supported on Linux, other platforms use temporary files.
+func memoryFile(data []byte) (string, func(), error) {
+	return "", nil, errors.New("memory files are not supported")
+}
@@ -0,0 +1,154 @@
+package bimg
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+)
+
+func TestBuiltinICCProfile(t *testing.T) {
+	if VipsMajorVersion <= 8 && VipsMinorVersion < 8 {
+		t.Skip("Skip test in libvips < 8.8")
+		return
+	}
+
+	for _, name := range []string{ICCProfileSRGB, ICCProfileCMYK} {
+		profile, err := BuiltinICCProfile(name)
+		if err != nil {
+			t.Fatalf("Cannot load the %s profile: %s", name, err)
+		}
+		// Every ICC profile has the "acsp" signature at offset 36
+		if len(profile) < 40 || string(profile[36:40]) != "acsp" {
+			t.Fatalf("Invalid %s profile", name)
+		}
+	}
+
+	if _, err := BuiltinICCProfile("unknown"); err == nil {
+		t.Fatal("Expected an error loading an unknown profile")
+	}
+}
+
+func TestICCProfile(t *testing.T) {
+	profile, err := ICCProfile(readFile("test_icc_prophoto.jpg"))
+	if err != nil {
+		t.Fatalf("Cannot read the image: %s", err)
+	}
+	if len(profile) == 0 {
+		t.Fatal("Expected the embedded ICC profile")
+	}
+
+	profile, err = ICCProfile(readFile("test.jpg"))
+	if err != nil {
+		t.Fatalf("Cannot read the image: %s", err)
+	}
+	if len(profile) != 0 {
+		t.Fatal("Unexpected ICC profile")
+	}
+}
+
+func TestEmbedICCProfile(t *testing.T) {
+	profile, err := ICCProfile(readFile("test_icc_prophoto.jpg"))
+	if err != nil {
+		t.Fatalf("Cannot read the image: %s", err)
+	}
+
+	newImg, err := NewImage(readFile("test.jpg")).EmbedICCProfile(profile)
+	if err != nil {
+		t.Fatalf("Cannot embed the ICC profile: %s", err)
+	}
+
+	embedded, err := NewImage(newImg).ICCProfile()
+	if err != nil {
+		t.Fatalf("Cannot read the image: %s", err)
+	}
+	if !bytes.Equal(embedded, profile) {
+		t.Fatal("Unexpected embedded ICC profile")
+	}
+
+	Write("testdata/test_icc_embed_out.jpg", newImg)
+}
+
+func TestOutputICCProfile(t *testing.T) {
+	if VipsMajorVersion <= 8 && VipsMinorVersion < 8 {
+		t.Skip("Skip test in libvips < 8.8")
+		return
+	}
+
+	srgb, err := BuiltinICCProfile(ICCProfileSRGB)
+	if err != nil {
+		t.Fatalf("Cannot load the sRGB profile: %s", err)
+	}
+	prophoto := readFile("test_icc_prophoto.jpg")
+	original, _ := ICCProfile(prophoto)
+
+	newImg, err := Resize(prophoto, Options{Width: 300, OutputICCProfile: srgb})
+	if err != nil {
+		t.Fatalf("Cannot transform the image: %s", err)
+	}
+
+	profile, err := ICCProfile(newImg)
+	if err != nil {
+		t.Fatalf("Cannot read the image: %s", err)
+	}
+	if len(profile) == 0 || bytes.Equal(profile, original) {
+		t.Fatal("Expected the output ICC profile to be embedded")
+	}
+
+	Write("testdata/test_icc_output_out.jpg", newImg)
+}
+
+func TestICCProfileFile(t *testing.T) {
+	profile := []byte("not really an icc profile")
+
+	for _, create := range []func([]byte) (string, func(), error){iccProfileFile, tempFile} {
+		path, release, err := create(profile)
+		if err != nil {
+			t.Fatalf("Cannot write the profile: %s", err)
+		}
+
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatalf("Cannot read the profile: %s", err)
+		}
+		if !bytes.Equal(data, profile) {
+			t.Fatalf("Unexpected profile content: %s", data)
+		}
+		release()
+	}
+}
+
+func TestOutputICCProfileSequence(t *testing.T) {
+	if VipsMajorVersion <= 8 && VipsMinorVersion < 8 {
+		t.Skip("Skip test in libvips < 8.8")
+		return
+	}
+
+	srgb, err := BuiltinICCProfile(ICCProfileSRGB)
+	if err != nil {
+		t.Fatalf("Cannot load the sRGB profile: %s", err)
+	}
+	prophoto := readFile("test_icc_prophoto.jpg")
+	original, _ := ICCProfile(prophoto)
+
+	// Each conversion must use its own profile, not a cached one
+	var outputs [][]byte
+	for _, profile := range [][]byte{srgb, original} {
+		newImg, err := Resize(prophoto, Options{Width: 300, Type: PNG, OutputICCProfile: profile})
+		if err != nil {
+			t.Fatalf("Cannot transform the image: %s", err)
+		}
+
+		embedded, err := ICCProfile(newImg)
+		if err != nil {
+			t.Fatalf("Cannot read the image: %s", err)
+		}
+		if !bytes.Equal(embedded, profile) {
+			t.Fatal("Expected the output ICC profile to be embedded")
+		}
+		outputs = append(outputs, newImg)
+	}
+
+	if bytes.Equal(outputs[0], outputs[1]) {
+		t.Fatal("Expected the outputs of different profiles to differ")
+	}
+}
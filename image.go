@@ -196,6 +196,36 @@ func (i *Image) Gamma(exponent float64) ([]byte, error) {
 	return i.Process(options)
 }
 
+// Modulate returns the image buffer with adjusted brightness, contrast, saturation and hue.
+func (i *Image) Modulate(m Modulate) ([]byte, error) {
+	options := Options{Modulate: m}
+	return i.Process(options)
+}
+
+// Greyscale converts the image to grey, keeping the sRGB colourspace.
+func (i *Image) Greyscale() ([]byte, error) {
+	options := Options{Greyscale: true}
+	return i.Process(options)
+}
+
+// Sepia applies a sepia tone to the image.
+func (i *Image) Sepia() ([]byte, error) {
+	options := Options{Sepia: true}
+	return i.Process(options)
+}
+
+// Tint recolours the image with the given colour, keeping its lightness.
+func (i *Image) Tint(c Color) ([]byte, error) {
+	options := Options{Tint: c}
+	return i.Process(options)
+}
+
+// Duotone maps the image lightness from the shadows to the highlights colour.
+func (i *Image) Duotone(shadows, highlights Color) ([]byte, error) {
+	options := Options{Duotone: [2]Color{shadows, highlights}}
+	return i.Process(options)
+}
+
 // Process processes the image based on the given transformation options,
 // talking with libvips bindings accordingly and returning the resultant
 // image buffer.
@@ -213,6 +243,16 @@ func (i *Image) Metadata() (ImageMetadata, error) {
 	return Metadata(i.buffer)
 }
 
+// DominantColors returns up to n representative colours of the image.
+func (i *Image) DominantColors(n int) ([]Color, error) {
+	return DominantColors(i.buffer, n)
+}
+
+// AverageColor returns the average colour of the image.
+func (i *Image) AverageColor() (Color, error) {
+	return AverageColor(i.buffer)
+}
+
 // Interpretation gets the image interpretation type.
 // See: https://libvips.github.io/libvips/API/current/VipsImage.html#VipsInterpretation
 func (i *Image) Interpretation() (Interpretation, error) {
@@ -235,6 +275,22 @@ func (i *Image) Size() (ImageSize, error) {
 	return Size(i.buffer)
 }
 
+// EmbedICCProfile embeds the given ICC profile without transforming the pixels.
+func (i *Image) EmbedICCProfile(profile []byte) ([]byte, error) {
+	options := Options{EmbedICCProfile: profile}
+	return i.Process(options)
+}
+
+// ICCProfile returns the ICC profile embedded in the image, if any.
+func (i *Image) ICCProfile() ([]byte, error) {
+	return ICCProfile(i.buffer)
+}
+
+// DisplaySize returns the image size once auto-rotated according to its EXIF orientation.
+func (i *Image) DisplaySize() (ImageSize, error) {
+	return DisplaySize(i.buffer)
+}
+
 // Image returns the current resultant image buffer.
 func (i *Image) Image() []byte {
 	return i.buffer
@@ -451,6 +451,79 @@ func TestImageColourspace(t *testing.T) {
 	}
 }
 
+func TestImageModulate(t *testing.T) {
+	buf, err := initImage("test.jpg").Modulate(Modulate{Brightness: float64Ptr(1.1), Saturation: float64Ptr(0.5)})
+	if err != nil {
+		t.Errorf("Cannot process the image: %#v", err)
+	}
+
+	err = assertSize(buf, 1680, 1050)
+	if err != nil {
+		t.Error(err)
+	}
+
+	Write("testdata/test_image_modulate_out.jpg", buf)
+}
+
+func TestImageColourFilters(t *testing.T) {
+	tests := []struct {
+		name      string
+		filter    func(i *Image) ([]byte, error)
+		grayscale bool
+	}{
+		{"greyscale", func(i *Image) ([]byte, error) { return i.Greyscale() }, true},
+		{"sepia", func(i *Image) ([]byte, error) { return i.Sepia() }, false},
+		{"tint", func(i *Image) ([]byte, error) { return i.Tint(Color{255, 120, 0}) }, false},
+		{"duotone", func(i *Image) ([]byte, error) { return i.Duotone(Color{20, 0, 80}, Color{255, 220, 120}) }, false},
+	}
+
+	for _, test := range tests {
+		image := initImage("test.jpg")
+		_, err := image.Process(Options{Width: 400, Type: PNG})
+		if err != nil {
+			t.Fatalf("Cannot process the image: %s", err)
+		}
+
+		buf, err := test.filter(image)
+		if err != nil {
+			t.Fatalf("Cannot apply the %s filter: %s", test.name, err)
+		}
+
+		metadata, err := Metadata(buf)
+		if err != nil {
+			t.Fatalf("Cannot read the metadata: %s", err)
+		}
+		if metadata.Channels != 3 || metadata.Space != "srgb" {
+			t.Fatalf("Unexpected %s output: %d channels, %s space", test.name, metadata.Channels, metadata.Space)
+		}
+
+		stats, err := Stats(buf)
+		if err != nil {
+			t.Fatalf("Cannot compute the image stats: %s", err)
+		}
+		if stats.Grayscale != test.grayscale {
+			t.Fatalf("Unexpected %s grayscale flag: %t", test.name, stats.Grayscale)
+		}
+
+		Write(fmt.Sprintf("testdata/test_image_%s_out.png", test.name), buf)
+	}
+}
+
+func TestImageColourFiltersTransparent(t *testing.T) {
+	buf, err := initImage("transparent.png").Sepia()
+	if err != nil {
+		t.Fatalf("Cannot process the image: %s", err)
+	}
+
+	metadata, err := Metadata(buf)
+	if err != nil {
+		t.Fatalf("Cannot read the metadata: %s", err)
+	}
+	if !metadata.Alpha {
+		t.Fatal("The alpha channel must be preserved")
+	}
+}
+
 func TestImageColourspaceIsSupported(t *testing.T) {
 	supported, err := initImage("test.jpg").ColourspaceIsSupported()
 	if err != nil {
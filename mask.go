@@ -0,0 +1,126 @@
+package bimg
+
+/*
+#cgo pkg-config: vips
+#include "vips/vips.h"
+*/
+import "C"
+
+import (
+	"math"
+)
+
+// MaskShape represents the shape of the image mask.
+type MaskShape int
+
+const (
+	// MaskNone applies no shape mask.
+	MaskNone MaskShape = iota
+	// MaskCircle keeps the largest circle centered in the image.
+	MaskCircle
+	// MaskEllipse keeps the ellipse inscribed in the image.
+	MaskEllipse
+	// MaskRoundedRect keeps the image with rounded corners of the given Radius.
+	MaskRoundedRect
+)
+
+// Mask represents the transparency mask applied to the output image.
+// The areas outside of the mask become transparent, so JPEG output
+// is converted to PNG unless a Background colour is defined.
+type Mask struct {
+	Shape MaskShape
+	// Radius defines the corners radius of MaskRoundedRect, in output pixels.
+	Radius int
+	// Buf defines a mask image, scaled to the output size, used instead of
+	// the Shape. Its alpha channel, or its luminance if opaque, defines
+	// the opacity of every pixel.
+	Buf []byte
+}
+
+func (m Mask) isEmpty() bool {
+	return m.Shape == MaskNone && len(m.Buf) == 0
+}
+
+// maskImage makes the areas of the image outside of the mask transparent.
+func maskImage(image *C.VipsImage, m Mask) (*C.VipsImage, error) {
+	if m.isEmpty() {
+		return image, nil
+	}
+
+	width, height := int(image.Xsize), int(image.Ysize)
+
+	var mask *C.VipsImage
+	var err error
+	if len(m.Buf) > 0 {
+		mask, err = loadMaskImage(m.Buf, width, height)
+	} else {
+		mask, err = vipsImageFromPixels(maskPixels(m.Shape, width, height, m.Radius), width, height, 1)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer C.g_object_unref(C.gpointer(mask))
+
+	return vipsApplyMask(image, mask)
+}
+
+func loadMaskImage(buf []byte, width, height int) (*C.VipsImage, error) {
+	image, _, err := vipsRead(buf)
+	if err != nil {
+		return nil, err
+	}
+	return vipsMaskFromImage(image, width, height)
+}
+
+// maskPixels returns the antialiased 8-bit opacity of the shape.
+func maskPixels(shape MaskShape, width, height, radius int) []byte {
+	w, h := float64(width), float64(height)
+	cx, cy := w/2, h/2
+
+	pixels := make([]byte, width*height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			// Signed distance from the pixel center to the shape edge,
+			// negative inside the shape
+			px, py := float64(x)+0.5-cx, float64(y)+0.5-cy
+
+			var d float64
+			switch shape {
+			case MaskCircle:
+				d = math.Hypot(px, py) - math.Min(w, h)/2
+			case MaskEllipse:
+				d = ellipseDistance(px, py, w/2, h/2)
+			case MaskRoundedRect:
+				d = roundedRectDistance(px, py, w/2, h/2, math.Min(float64(radius), math.Min(w, h)/2))
+			default:
+				d = -1
+			}
+
+			coverage := math.Max(0, math.Min(1, 0.5-d))
+			pixels[y*width+x] = byte(roundFloat(coverage * 255))
+		}
+	}
+	return pixels
+}
+
+// ellipseDistance approximates the signed distance to the ellipse
+// with semi-axes a and b, dividing its implicit function by its gradient.
+func ellipseDistance(x, y, a, b float64) float64 {
+	f := x*x/(a*a) + y*y/(b*b) - 1
+	gx, gy := 2*x/(a*a), 2*y/(b*b)
+	gradient := math.Hypot(gx, gy)
+	if gradient == 0 {
+		return -math.Min(a, b)
+	}
+	return f / gradient
+}
+
+// roundedRectDistance returns the signed distance to the rectangle
+// with half sizes hw and hh and corners of radius r.
+func roundedRectDistance(x, y, hw, hh, r float64) float64 {
+	qx := math.Abs(x) - (hw - r)
+	qy := math.Abs(y) - (hh - r)
+	outside := math.Hypot(math.Max(qx, 0), math.Max(qy, 0))
+	inside := math.Min(math.Max(qx, qy), 0)
+	return outside + inside - r
+}
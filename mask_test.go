@@ -0,0 +1,82 @@
+package bimg
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestMask(t *testing.T) {
+	masks := []struct {
+		name string
+		mask Mask
+	}{
+		{"circle", Mask{Shape: MaskCircle}},
+		{"ellipse", Mask{Shape: MaskEllipse}},
+		{"rounded", Mask{Shape: MaskRoundedRect, Radius: 40}},
+		{"image", Mask{Buf: readImage("transparent.png")}},
+	}
+	buf, _ := Read("testdata/test.jpg")
+
+	for _, m := range masks {
+		newImg, err := Resize(buf, Options{Width: 300, Height: 200, Crop: true, Mask: m.mask})
+		if err != nil {
+			t.Fatalf("Cannot apply the %s mask: %s", m.name, err)
+		}
+
+		if DetermineImageType(newImg) != PNG {
+			t.Fatalf("The %s mask output must be converted to png", m.name)
+		}
+		if err := assertSize(newImg, 300, 200); err != nil {
+			t.Fatal(err)
+		}
+
+		stats, err := Stats(newImg)
+		if err != nil {
+			t.Fatalf("Cannot compute the image stats: %s", err)
+		}
+		if !stats.UsesAlpha {
+			t.Fatalf("Expected transparent pixels for the %s mask", m.name)
+		}
+
+		Write(fmt.Sprintf("testdata/test_mask_%s_out.png", m.name), newImg)
+	}
+}
+
+func TestMaskBackground(t *testing.T) {
+	options := Options{Width: 300, Height: 300, Crop: true, Mask: Mask{Shape: MaskCircle}, Background: Color{255, 255, 255}}
+	buf, _ := Read("testdata/test.jpg")
+
+	newImg, err := Resize(buf, options)
+	if err != nil {
+		t.Fatalf("Resize(imgData, %#v) error: %#v", options, err)
+	}
+	if DetermineImageType(newImg) != JPEG {
+		t.Fatal("Image is not jpeg")
+	}
+
+	Write("testdata/test_mask_background_out.jpg", newImg)
+}
+
+func TestMaskPixels(t *testing.T) {
+	pixels := maskPixels(MaskCircle, 10, 10, 0)
+	if pixels[0] != 0 || pixels[5*10+5] != 255 {
+		t.Fatalf("Unexpected circle mask: %v", pixels)
+	}
+
+	// Antialiased edge
+	if edge := pixels[5*10]; edge == 0 || edge == 255 {
+		t.Fatalf("Expected a partially transparent edge: %d", edge)
+	}
+
+	pixels = maskPixels(MaskRoundedRect, 10, 6, 0)
+	for i, p := range pixels {
+		if p != 255 {
+			t.Fatalf("Unexpected transparent pixel %d without radius: %d", i, p)
+		}
+	}
+
+	pixels = maskPixels(MaskRoundedRect, 20, 20, 5)
+	if pixels[0] != 0 || pixels[10] != 255 || pixels[10*20] != 255 {
+		t.Fatalf("Unexpected rounded rect mask: %v", pixels)
+	}
+}
@@ -6,6 +6,12 @@ package bimg
 */
 import "C"
 
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
 // Common EXIF fields for data extraction
 const (
 	Make                    = "exif-ifd0-Make"
@@ -15,6 +21,9 @@ const (
 	YResolution             = "exif-ifd0-YResolution"
 	ResolutionUnit          = "exif-ifd0-ResolutionUnit"
 	Software                = "exif-ifd0-Software"
+	Artist                  = "exif-ifd0-Artist"
+	Copyright               = "exif-ifd0-Copyright"
+	ImageDescription        = "exif-ifd0-ImageDescription"
 	Datetime                = "exif-ifd0-DateTime"
 	YCbCrPositioning        = "exif-ifd0-YCbCrPositioning"
 	Compression             = "exif-ifd1-Compression"
@@ -36,6 +45,7 @@ const (
 	SubjectArea             = "exif-ifd2-SubjectArea"
 	MakerNote               = "exif-ifd2-MakerNote"
 	SubSecTimeOriginal      = "exif-ifd2-SubSecTimeOriginal"
+	OffsetTimeOriginal      = "exif-ifd2-OffsetTimeOriginal"
 	SubSecTimeDigitized     = "exif-ifd2-SubSecTimeDigitized"
 	ColorSpace              = "exif-ifd2-ColorSpace"
 	PixelXDimension         = "exif-ifd2-PixelXDimension"
@@ -61,6 +71,20 @@ const (
 	GPSDateStamp            = "exif-ifd3-GPSDateStamp"
 )
 
+// libvips metadata fields
+const (
+	orientationField = "orientation"
+	exifField        = "exif-data"
+	exifPrefix       = "exif-"
+	gpsPrefix        = "exif-ifd3-"
+	xmpField         = "xmp-data"
+	iptcField        = "iptc-data"
+	iccField         = "icc-profile-data"
+	pngCommentPrefix = "png-comment-"
+	gifCommentField  = "gif-comment"
+	jpegCommentField = "jpeg-comment"
+)
+
 // ImageSize represents the image width and height values
 type ImageSize struct {
 	Width  int
@@ -77,7 +101,27 @@ type ImageMetadata struct {
 	Space       string
 	Colourspace string
 	Size        ImageSize
+	// DisplaySize is the size of the image once auto-rotated
+	// according to its EXIF orientation.
+	DisplaySize ImageSize
 	EXIF        EXIF
+	// Fields holds every libvips metadata field formatted as a string,
+	// such as "exif-ifd2-LensModel", except the binary blobs.
+	Fields map[string]string
+	// XMP, IPTC and ICC hold the raw metadata blobs, if present.
+	XMP  []byte
+	IPTC []byte
+	ICC  []byte
+	// DominantColors and AverageColor are only filled when
+	// requested via MetadataOptions.Colors.
+	DominantColors []Color
+	AverageColor   Color
+}
+
+// MetadataOptions represents the optional metadata to be extracted.
+type MetadataOptions struct {
+	// Colors defines the number of dominant colours to extract.
+	Colors int
 }
 
 // EXIF image metadata
@@ -89,6 +133,9 @@ type EXIF struct {
 	YResolution             string
 	ResolutionUnit          int
 	Software                string
+	Artist                  string
+	Copyright               string
+	ImageDescription        string
 	Datetime                string
 	YCbCrPositioning        int
 	Compression             int
@@ -110,6 +157,7 @@ type EXIF struct {
 	SubjectArea             string
 	MakerNote               string
 	SubSecTimeOriginal      string
+	OffsetTimeOriginal      string
 	SubSecTimeDigitized     string
 	ColorSpace              int
 	PixelXDimension         int
@@ -148,6 +196,17 @@ func Size(buf []byte) (ImageSize, error) {
 	}, nil
 }
 
+// DisplaySize returns the image size once auto-rotated according to its
+// EXIF orientation, as produced by Resize unless NoAutoRotate is set.
+func DisplaySize(buf []byte) (ImageSize, error) {
+	probe, err := Probe(buf)
+	if err != nil {
+		return ImageSize{}, err
+	}
+
+	return probe.DisplaySize, nil
+}
+
 // ColourspaceIsSupported checks if the image colourspace is supported by libvips.
 func ColourspaceIsSupported(buf []byte) (bool, error) {
 	return vipsColourspaceIsSupportedBuffer(buf)
@@ -161,6 +220,11 @@ func ImageInterpretation(buf []byte) (Interpretation, error) {
 
 // Metadata returns the image metadata (size, type, alpha channel, profile, EXIF orientation...).
 func Metadata(buf []byte) (ImageMetadata, error) {
+	return MetadataWithOptions(buf, MetadataOptions{})
+}
+
+// MetadataWithOptions returns the image metadata, including the optional fields requested by o.
+func MetadataWithOptions(buf []byte, o MetadataOptions) (ImageMetadata, error) {
 	defer C.vips_thread_shutdown()
 
 	image, imageType, err := vipsRead(buf)
@@ -184,6 +248,7 @@ func Metadata(buf []byte) (ImageMetadata, error) {
 		Profile:     vipsHasProfile(image),
 		Space:       vipsSpace(image),
 		Type:        ImageTypeName(imageType),
+		DisplaySize: displaySize(size, orientation),
 		EXIF: EXIF{
 			Make:                    vipsExifStringTag(image, Make),
 			Model:                   vipsExifStringTag(image, Model),
@@ -192,6 +257,9 @@ func Metadata(buf []byte) (ImageMetadata, error) {
 			YResolution:             vipsExifStringTag(image, YResolution),
 			ResolutionUnit:          vipsExifIntTag(image, ResolutionUnit),
 			Software:                vipsExifStringTag(image, Software),
+			Artist:                  vipsExifStringTag(image, Artist),
+			Copyright:               vipsExifStringTag(image, Copyright),
+			ImageDescription:        vipsExifStringTag(image, ImageDescription),
 			Datetime:                vipsExifStringTag(image, Datetime),
 			YCbCrPositioning:        vipsExifIntTag(image, YCbCrPositioning),
 			Compression:             vipsExifIntTag(image, Compression),
@@ -213,6 +281,7 @@ func Metadata(buf []byte) (ImageMetadata, error) {
 			SubjectArea:             vipsExifStringTag(image, SubjectArea),
 			MakerNote:               vipsExifStringTag(image, MakerNote),
 			SubSecTimeOriginal:      vipsExifStringTag(image, SubSecTimeOriginal),
+			OffsetTimeOriginal:      vipsExifStringTag(image, OffsetTimeOriginal),
 			SubSecTimeDigitized:     vipsExifStringTag(image, SubSecTimeDigitized),
 			ColorSpace:              vipsExifIntTag(image, ColorSpace),
 			PixelXDimension:         vipsExifIntTag(image, PixelXDimension),
@@ -237,7 +306,140 @@ func Metadata(buf []byte) (ImageMetadata, error) {
 			GPSDestBearing:          vipsExifStringTag(image, GPSDestBearing),
 			GPSDateStamp:            vipsExifStringTag(image, GPSDateStamp),
 		},
+		Fields: imageFields(image),
+		XMP:    vipsImageBlob(image, xmpField),
+		IPTC:   vipsImageBlob(image, iptcField),
+		ICC:    vipsImageBlob(image, iccField),
+	}
+
+	if o.Colors > 0 {
+		pixels, err := colorPixels(buf)
+		if err != nil {
+			return ImageMetadata{}, err
+		}
+		metadata.DominantColors = medianCut(pixels, o.Colors)
+		metadata.AverageColor = averageColor(pixels)
 	}
 
 	return metadata, nil
 }
+
+// imageFields returns the string metadata fields of the image.
+// EXIF values are stripped from their libvips type description.
+func imageFields(image *C.VipsImage) map[string]string {
+	fields := make(map[string]string)
+	for _, name := range vipsImageFields(image) {
+		value, ok := vipsImageFieldString(image, name)
+		if !ok {
+			continue
+		}
+		if strings.HasPrefix(name, exifPrefix) {
+			value = vipsExifShort(value)
+		}
+		fields[name] = value
+	}
+	return fields
+}
+
+// writeMetadata sets and removes the EXIF, XMP, IPTC and ICC metadata
+// of a copy of the image, as requested by the options.
+func writeMetadata(image *C.VipsImage, o Options) (*C.VipsImage, error) {
+	if len(o.SetEXIF) == 0 && len(o.SetXMP) == 0 && len(o.SetIPTC) == 0 && !o.RemoveXMP && !o.RemoveIPTC &&
+		o.Keep == 0 && len(o.RemoveFields) == 0 && len(o.EmbedICCProfile) == 0 {
+		return image, nil
+	}
+
+	image, err := vipsCopy(image)
+	if err != nil {
+		return nil, err
+	}
+
+	removedEXIF := false
+	if o.Keep != 0 {
+		for _, name := range vipsImageFields(image) {
+			if isMetadataField(name) && !keepField(name, o.Keep) {
+				vipsRemoveField(image, name)
+				removedEXIF = removedEXIF || strings.HasPrefix(name, exifPrefix)
+			}
+		}
+	}
+	for _, name := range o.RemoveFields {
+		vipsRemoveField(image, name)
+		removedEXIF = removedEXIF || strings.HasPrefix(name, exifPrefix)
+	}
+
+	// libvips drops the EXIF entries of the removed fields from 8.10 only,
+	// older versions would save them from the EXIF block, such as the GPS
+	// position, so the whole block is removed instead
+	if removedEXIF && (VipsMajorVersion < 8 || (VipsMajorVersion == 8 && VipsMinorVersion < 10)) {
+		vipsRemoveField(image, exifField)
+	}
+
+	for name, value := range o.SetEXIF {
+		vipsSetString(image, name, value)
+
+		// libvips writes the orientation from its own field
+		if name == Orientation {
+			orientation, err := strconv.Atoi(value)
+			if err != nil {
+				C.g_object_unref(C.gpointer(image))
+				return nil, fmt.Errorf("invalid orientation: %s", value)
+			}
+			vipsSetInt(image, orientationField, orientation)
+		}
+	}
+
+	if o.RemoveXMP {
+		vipsRemoveField(image, xmpField)
+	}
+	if len(o.SetXMP) > 0 {
+		vipsSetBlob(image, xmpField, o.SetXMP)
+	}
+
+	if o.RemoveIPTC {
+		vipsRemoveField(image, iptcField)
+	}
+	if len(o.SetIPTC) > 0 {
+		vipsSetBlob(image, iptcField, o.SetIPTC)
+	}
+
+	if len(o.EmbedICCProfile) > 0 {
+		vipsSetBlob(image, iccField, o.EmbedICCProfile)
+	}
+
+	return image, nil
+}
+
+// isMetadataField reports whether the libvips field holds EXIF, XMP,
+// IPTC or ICC metadata or a text comment, as opposed to the loader
+// and saver hints.
+func isMetadataField(name string) bool {
+	switch name {
+	case orientationField, xmpField, iptcField, iccField, gifCommentField, jpegCommentField:
+		return true
+	}
+	return strings.HasPrefix(name, exifPrefix) || strings.HasPrefix(name, pngCommentPrefix)
+}
+
+// keepField reports whether the metadata field is kept by the flags.
+// libvips drops the EXIF entries whose field was removed on save.
+func keepField(name string, keep KeepMetadata) bool {
+	switch name {
+	case xmpField:
+		return keep&KeepXMP != 0
+	case iptcField:
+		return keep&KeepIPTC != 0
+	case iccField:
+		return keep&KeepICC != 0
+	case gifCommentField, jpegCommentField:
+		return false
+	}
+	if strings.HasPrefix(name, pngCommentPrefix) {
+		return false
+	}
+
+	if keep&KeepEXIF != 0 {
+		return true
+	}
+	return keep&KeepEXIFNoGPS != 0 && !strings.HasPrefix(name, gpsPrefix)
+}
@@ -1,6 +1,8 @@
 package bimg
 
 import (
+	"bytes"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path"
@@ -68,6 +70,45 @@ func TestMetadata(t *testing.T) {
 	}
 }
 
+func TestDisplaySize(t *testing.T) {
+	for orientation := 1; orientation <= 8; orientation++ {
+		buf := readFile(fmt.Sprintf("exif/Landscape_%d.jpg", orientation))
+
+		rotated, err := NewImage(buf).AutoRotate()
+		if err != nil {
+			t.Fatalf("Cannot auto-rotate the image: %s", err)
+		}
+		expected, err := Size(rotated)
+		if err != nil {
+			t.Fatalf("Cannot read the image: %s", err)
+		}
+
+		size, err := DisplaySize(buf)
+		if err != nil {
+			t.Fatalf("Cannot read the image: %s", err)
+		}
+		if size != expected {
+			t.Fatalf("Unexpected display size for orientation %d: %#v != %#v", orientation, size, expected)
+		}
+
+		metadata, err := Metadata(buf)
+		if err != nil {
+			t.Fatalf("Cannot read the image: %s", err)
+		}
+		if metadata.DisplaySize != expected {
+			t.Fatalf("Unexpected metadata display size for orientation %d: %#v != %#v", orientation, metadata.DisplaySize, expected)
+		}
+	}
+
+	size, err := NewImage(readFile("exif/Landscape_6.jpg")).DisplaySize()
+	if err != nil {
+		t.Fatalf("Cannot read the image: %s", err)
+	}
+	if size.Width != 1600 || size.Height != 1200 {
+		t.Fatalf("Unexpected display size: %#v", size)
+	}
+}
+
 func TestImageInterpretation(t *testing.T) {
 	files := []struct {
 		name           string
@@ -372,6 +413,233 @@ func TestEXIF(t *testing.T) {
 	}
 }
 
+func TestMetadataFields(t *testing.T) {
+	if VipsMajorVersion <= 8 && VipsMinorVersion < 10 {
+		t.Skip("Skip test in libvips < 8.10")
+		return
+	}
+
+	metadata, err := Metadata(readFile("test_exif_full.jpg"))
+	if err != nil {
+		t.Fatalf("Cannot read the image: %s", err)
+	}
+
+	fields := map[string]string{
+		Make:         "Apple",
+		Model:        "iPhone XS",
+		GPSDateStamp: "2020:07:28",
+	}
+	for name, value := range fields {
+		if metadata.Fields[name] != value {
+			t.Fatalf("Unexpected image field %s: %s != %s", name, metadata.Fields[name], value)
+		}
+	}
+	if _, ok := metadata.Fields["exif-data"]; ok {
+		t.Fatal("Unexpected binary field exif-data")
+	}
+
+	metadata, err = Metadata(readFile("test_icc_prophoto.jpg"))
+	if err != nil {
+		t.Fatalf("Cannot read the image: %s", err)
+	}
+	if len(metadata.ICC) == 0 {
+		t.Fatal("Expected the image ICC profile")
+	}
+}
+
+func TestWriteEXIF(t *testing.T) {
+	if VipsMajorVersion <= 8 && VipsMinorVersion < 10 {
+		t.Skip("Skip test in libvips < 8.10")
+		return
+	}
+
+	options := Options{
+		Width: 300,
+		SetEXIF: map[string]string{
+			Artist:           "Jane",
+			Copyright:        "ACME",
+			ImageDescription: "A test image",
+			Orientation:      "6",
+		},
+	}
+	newImg, err := Resize(readFile("test.jpg"), options)
+	if err != nil {
+		t.Fatalf("Cannot write the image metadata: %s", err)
+	}
+
+	metadata, err := Metadata(newImg)
+	if err != nil {
+		t.Fatalf("Cannot read the image: %s", err)
+	}
+	if metadata.EXIF.Artist != "Jane" {
+		t.Fatalf("Unexpected image exif Artist: %s", metadata.EXIF.Artist)
+	}
+	if metadata.EXIF.Copyright != "ACME" {
+		t.Fatalf("Unexpected image exif Copyright: %s", metadata.EXIF.Copyright)
+	}
+	if metadata.EXIF.ImageDescription != "A test image" {
+		t.Fatalf("Unexpected image exif ImageDescription: %s", metadata.EXIF.ImageDescription)
+	}
+	if metadata.Orientation != 6 {
+		t.Fatalf("Unexpected image orientation: %d", metadata.Orientation)
+	}
+
+	_, err = Resize(readFile("test.jpg"), Options{SetEXIF: map[string]string{Orientation: "left"}})
+	if err == nil {
+		t.Fatal("Expected an invalid orientation error")
+	}
+}
+
+func TestWriteXMP(t *testing.T) {
+	xmp := []byte(`<x:xmpmeta xmlns:x="adobe:ns:meta/"><rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">` +
+		`<rdf:Description xmlns:dc="http://purl.org/dc/elements/1.1/" dc:creator="bimg"/></rdf:RDF></x:xmpmeta>`)
+
+	newImg, err := Resize(readFile("test.jpg"), Options{Width: 300, SetXMP: xmp})
+	if err != nil {
+		t.Fatalf("Cannot write the image metadata: %s", err)
+	}
+	if !bytes.Contains(newImg, xmp) {
+		t.Fatal("Expected the XMP packet in the output image")
+	}
+
+	metadata, err := Metadata(newImg)
+	if err != nil {
+		t.Fatalf("Cannot read the image: %s", err)
+	}
+	if !bytes.Contains(metadata.XMP, xmp) {
+		t.Fatalf("Unexpected image XMP: %s", metadata.XMP)
+	}
+
+	newImg, err = Resize(newImg, Options{Width: 200, RemoveXMP: true})
+	if err != nil {
+		t.Fatalf("Cannot remove the image metadata: %s", err)
+	}
+	if bytes.Contains(newImg, xmp) {
+		t.Fatal("Unexpected XMP packet in the output image")
+	}
+}
+
+func TestKeepMetadata(t *testing.T) {
+	if VipsMajorVersion <= 8 && VipsMinorVersion < 10 {
+		t.Skip("Skip test in libvips < 8.10")
+		return
+	}
+
+	options := Options{Width: 300, Keep: KeepEXIFNoGPS, RemoveFields: []string{Model}}
+	newImg, err := Resize(readFile("test_exif_full.jpg"), options)
+	if err != nil {
+		t.Fatalf("Cannot strip the image metadata: %s", err)
+	}
+
+	metadata, err := Metadata(newImg)
+	if err != nil {
+		t.Fatalf("Cannot read the image: %s", err)
+	}
+	if metadata.EXIF.Make != "Apple" {
+		t.Fatalf("Unexpected image exif Make: %s", metadata.EXIF.Make)
+	}
+	if metadata.EXIF.Model != "" {
+		t.Fatalf("Unexpected image exif Model: %s", metadata.EXIF.Model)
+	}
+	if metadata.EXIF.GPSLatitude != "" || metadata.EXIF.GPSLongitude != "" {
+		t.Fatalf("Unexpected image exif GPS: %s %s", metadata.EXIF.GPSLatitude, metadata.EXIF.GPSLongitude)
+	}
+
+	options = Options{Width: 300, Keep: KeepICC, StripMetadata: true}
+	newImg, err = Resize(readFile("test_icc_prophoto.jpg"), options)
+	if err != nil {
+		t.Fatalf("Cannot strip the image metadata: %s", err)
+	}
+
+	metadata, err = Metadata(newImg)
+	if err != nil {
+		t.Fatalf("Cannot read the image: %s", err)
+	}
+	if !metadata.Profile {
+		t.Fatal("Expected the image ICC profile to be kept")
+	}
+
+	newImg, err = Resize(readFile("test_exif_full.jpg"), Options{Width: 300, Keep: KeepXMP})
+	if err != nil {
+		t.Fatalf("Cannot strip the image metadata: %s", err)
+	}
+
+	metadata, err = Metadata(newImg)
+	if err != nil {
+		t.Fatalf("Cannot read the image: %s", err)
+	}
+	if metadata.EXIF.Make != "" || metadata.EXIF.GPSLatitude != "" {
+		t.Fatalf("Unexpected image exif: %#v", metadata.EXIF)
+	}
+
+	// The text comments are stripped too
+	comment := []byte("tEXtComment")
+	options = Options{Type: PNG, SetEXIF: map[string]string{"png-comment-0-Comment": "bimg"}}
+	commented, err := Resize(readFile("test.png"), options)
+	if err != nil {
+		t.Fatalf("Cannot set the image comment: %s", err)
+	}
+	if !bytes.Contains(commented, comment) {
+		t.Fatal("Expected the image comment to be set")
+	}
+
+	newImg, err = Resize(commented, Options{Keep: KeepICC})
+	if err != nil {
+		t.Fatalf("Cannot strip the image metadata: %s", err)
+	}
+	if bytes.Contains(newImg, comment) {
+		t.Fatal("Unexpected image comment")
+	}
+}
+
+func TestKeepMetadataNoGPS(t *testing.T) {
+	// The GPS position must never be saved, whatever the libvips version
+	for _, options := range []Options{
+		{Width: 300, Keep: KeepEXIFNoGPS},
+		{Width: 300, Keep: KeepEXIF, RemoveFields: []string{GPSLatitude, GPSLongitude}},
+	} {
+		newImg, err := Resize(readFile("test_exif_full.jpg"), options)
+		if err != nil {
+			t.Fatalf("Cannot strip the image metadata: %s", err)
+		}
+
+		metadata, err := Metadata(newImg)
+		if err != nil {
+			t.Fatalf("Cannot read the image: %s", err)
+		}
+		if metadata.EXIF.GPSLatitude != "" || metadata.EXIF.GPSLongitude != "" {
+			t.Fatalf("Unexpected image exif GPS for %#v: %s %s", options, metadata.EXIF.GPSLatitude, metadata.EXIF.GPSLongitude)
+		}
+	}
+}
+
+func TestKeepField(t *testing.T) {
+	tt := []struct {
+		name     string
+		keep     KeepMetadata
+		expected bool
+	}{
+		{Make, KeepEXIF, true},
+		{GPSLatitude, KeepEXIFNoGPS, false},
+		{iccField, KeepICC, true},
+		{xmpField, KeepEXIF, false},
+		{"png-comment-0-Comment", KeepEXIF, false},
+		{gifCommentField, KeepEXIF | KeepXMP | KeepIPTC | KeepICC, false},
+	}
+
+	for _, tc := range tt {
+		if !isMetadataField(tc.name) {
+			t.Fatalf("Expected %s to be a metadata field", tc.name)
+		}
+		if got := keepField(tc.name, tc.keep); got != tc.expected {
+			t.Fatalf("expected: %t; got: %t for %s", tc.expected, got, tc.name)
+		}
+	}
+	if isMetadataField("page-height") {
+		t.Fatal("Unexpected metadata field page-height")
+	}
+}
+
 func TestColourspaceIsSupported(t *testing.T) {
 	files := []struct {
 		name string
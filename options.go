@@ -186,6 +186,172 @@ type Sharpen struct {
 	M2     float64
 }
 
+// Filter represents the built-in convolution filters.
+// Its values match the order of the filters enum in vips.h.
+type Filter int
+
+const (
+	// FilterNone applies no filter.
+	FilterNone Filter = iota
+	// FilterEmboss gives the image a relief effect.
+	FilterEmboss
+	// FilterSobel returns the greyscale Sobel edges of the image.
+	FilterSobel
+	// FilterCanny returns the greyscale Canny edges of the image (libvips 8.8+).
+	FilterCanny
+	// FilterBoxBlur averages every pixel with its FilterSize x FilterSize neighbours.
+	FilterBoxBlur
+	// FilterMedian replaces every pixel with the median of its
+	// FilterSize x FilterSize neighbours, removing noise.
+	FilterMedian
+	// FilterDetail boosts the fine details of the image with unsharp masking.
+	FilterDetail
+)
+
+// Convolution represents a custom convolution kernel.
+type Convolution struct {
+	Width  int
+	Height int
+	// Kernel holds the Width x Height matrix values, row by row.
+	Kernel []float64
+	// Scale divides the result, defaults to the sum of the kernel values or 1.
+	Scale float64
+	// Offset is added to the result after scaling.
+	Offset float64
+}
+
+// RedactMode represents the effect used to hide a region of the image.
+// Its values match the order of the redact_modes enum in vips.h.
+type RedactMode int
+
+const (
+	// RedactPixelate replaces the region with blocks of its average colour.
+	RedactPixelate RedactMode = iota
+	// RedactBlur applies a strong gaussian blur to the region.
+	RedactBlur
+)
+
+// Region represents a rectangular area of the image to be redacted, in the
+// coordinates of the source image after auto-rotation, rotation and flipping.
+type Region struct {
+	Left   int
+	Top    int
+	Width  int
+	Height int
+	Mode   RedactMode
+	// BlockSize defines the pixelation block size in source pixels. Defaults to 16.
+	BlockSize int
+	// Sigma defines the blur strength in source pixels. Defaults to 20.
+	Sigma float64
+}
+
+// Padding represents the space added around the image, in pixels.
+type Padding struct {
+	Top    int
+	Right  int
+	Bottom int
+	Left   int
+}
+
+// Border represents a solid border around the image.
+type Border struct {
+	Width int
+	Color Color
+}
+
+// Shadow represents a drop shadow, which takes the shape of the image
+// alpha channel. The image is extended to fit the shadow.
+type Shadow struct {
+	// Sigma defines the shadow blur.
+	Sigma   float64
+	OffsetX int
+	OffsetY int
+	Color   Color
+	// Opacity defines the shadow opacity, between 0 and 1. Defaults to 0.5.
+	Opacity float64
+}
+
+// CLAHE represents the contrast limited adaptive histogram equalisation options.
+type CLAHE struct {
+	// Width and Height define the size of the region around each pixel.
+	Width  int
+	Height int
+	// MaxSlope limits the contrast amplification, 0 means no limit.
+	MaxSlope int
+}
+
+// Modulate represents the lightness and colour adjustments, applied in LCh space.
+// Nil multipliers leave the image unchanged, so that zero can be reached.
+type Modulate struct {
+	// Brightness multiplies the lightness, e.g. 1.2 is 20% brighter
+	// and 0 is black.
+	Brightness *float64
+	// Contrast multiplies the lightness distance to the middle grey,
+	// 0 is a flat grey.
+	Contrast *float64
+	// Saturation multiplies the chroma, e.g. 0.5 halves the saturation
+	// and 0 is greyscale.
+	Saturation *float64
+	// Hue rotates the hue by the given degrees.
+	Hue float64
+}
+
+// ChromaSubsampling represents the JPEG chroma subsampling mode.
+// Its values match the libvips VipsForeignSubsample enum.
+type ChromaSubsampling int
+
+const (
+	// ChromaSubsamplingAuto uses 4:2:0 below quality 90, 4:4:4 otherwise.
+	ChromaSubsamplingAuto ChromaSubsampling = iota
+	// ChromaSubsampling420 always halves the chroma resolution.
+	ChromaSubsampling420
+	// ChromaSubsampling444 never subsamples the chroma.
+	ChromaSubsampling444
+)
+
+// JPEGOptions represents the advanced JPEG encoder options. TrellisQuant,
+// OvershootDeringing, OptimizeScans and QuantTable require libvips 8.5+
+// built with mozjpeg, and are ignored otherwise.
+type JPEGOptions struct {
+	ChromaSubsampling ChromaSubsampling
+	// DisableOptimizeCoding uses the standard Huffman tables instead
+	// of computing optimal ones, which is faster but larger.
+	DisableOptimizeCoding bool
+	// TrellisQuant applies trellis quantisation to each 8x8 block.
+	TrellisQuant bool
+	// OvershootDeringing reduces the ringing around black text on white.
+	OvershootDeringing bool
+	// OptimizeScans splits the spectrum of progressive images into
+	// optimal scans. It implies Interlace, even without mozjpeg.
+	OptimizeScans bool
+	// QuantTable selects one of the mozjpeg quantisation tables, 0 to 8.
+	QuantTable int
+}
+
+// JPEGPresetMozJPEG mirrors the defaults of the mozjpeg cjpeg encoder.
+var JPEGPresetMozJPEG = JPEGOptions{
+	TrellisQuant:       true,
+	OvershootDeringing: true,
+	OptimizeScans:      true,
+	QuantTable:         3,
+}
+
+// KeepMetadata represents the kinds of metadata kept in the output image.
+type KeepMetadata int
+
+const (
+	// KeepEXIF keeps the EXIF metadata, including the orientation.
+	KeepEXIF KeepMetadata = 1 << iota
+	// KeepEXIFNoGPS keeps the EXIF metadata except the GPS fields.
+	KeepEXIFNoGPS
+	// KeepXMP keeps the XMP metadata.
+	KeepXMP
+	// KeepIPTC keeps the IPTC metadata.
+	KeepIPTC
+	// KeepICC keeps the ICC profile.
+	KeepICC
+)
+
 // Options represents the supported image transformation options.
 type Options struct {
 	Height         int
@@ -230,7 +396,111 @@ type Options struct {
 	// 0-8 for AVIF encoding.
 	// 0-9 for PNG encoding.
 	Speed int
+	// MaxBytes defines the maximum size in bytes of the output image.
+	// The image is encoded again at decreasing Quality until it fits.
+	MaxBytes int
+	// MaxBytesResize allows to scale down the output image when it
+	// does not fit into MaxBytes even at the lowest quality.
+	MaxBytesResize bool
+	// TargetQuality defines the minimum structural similarity (SSIM), between
+	// 0 and 1, the lossy JPEG, WebP, HEIF and AVIF output must keep with the
+	// processed image. The lowest quality meeting the target is used.
+	TargetQuality float64
+	// Normalize stretches the image lightness to the full range.
+	Normalize bool
+	// NormalizeClip defines the percentage of the darkest and the
+	// brightest pixels clipped when normalizing.
+	NormalizeClip float64
+	// Equalize applies histogram equalisation to the image lightness.
+	Equalize bool
+	// CLAHE applies contrast limited adaptive histogram equalisation
+	// to the image lightness when Width and Height are defined.
+	CLAHE CLAHE
+	// Modulate adjusts the brightness, contrast, saturation and hue after resizing.
+	Modulate Modulate
+	// Greyscale converts the image to grey, keeping the sRGB 3 bands.
+	Greyscale bool
+	// Sepia applies a sepia tone to the image.
+	Sepia bool
+	// Tint recolours the image with the given colour, keeping its lightness.
+	Tint Color
+	// Duotone maps the image lightness from the shadows colour
+	// to the highlights colour, in this order.
+	Duotone [2]Color
+	// Convolve applies a custom convolution kernel to the image.
+	Convolve Convolution
+	// Filter applies a built-in convolution filter to the image.
+	Filter Filter
+	// FilterSize defines the window size of the box blur and median filters. Defaults to 3.
+	FilterSize int
+	// Redact pixelates or blurs the given regions of the image,
+	// such as faces or licence plates.
+	Redact []Region
+	// Mask makes the areas of the output image outside of the given shape
+	// or mask image transparent.
+	Mask Mask
+	// Border adds a solid border around the resized image.
+	Border Border
+	// Shadow adds a drop shadow around the resized image.
+	Shadow Shadow
+	// Padding adds space around the resized image, filled with the Background
+	// colour, or transparent if the image has an alpha channel.
+	Padding Padding
+	// RemoveBackground makes the pixels within Threshold of the Background
+	// colour connected to the image edges transparent. Threshold defaults
+	// to 10 and JPEG output is converted to PNG.
+	RemoveBackground bool
+	// Feather defines the blur sigma of the removed background edge.
+	// Defaults to 1, negative values disable it.
+	Feather float64
+	// SetEXIF sets the given EXIF fields, keyed by their libvips name such as
+	// bimg.Copyright or bimg.Orientation. Ignored when StripMetadata is set
+	// without Keep.
+	SetEXIF map[string]string
+	// SetXMP replaces the XMP metadata with the given packet.
+	SetXMP []byte
+	// SetIPTC replaces the IPTC metadata with the given block.
+	SetIPTC []byte
+	// RemoveXMP removes the XMP metadata.
+	RemoveXMP bool
+	// RemoveIPTC removes the IPTC metadata.
+	RemoveIPTC bool
+	// Keep strips the EXIF, XMP, IPTC and ICC metadata and the text comments,
+	// except the given kinds such as bimg.KeepEXIFNoGPS | bimg.KeepICC.
+	// The loader and saver hints are left untouched. It overrides StripMetadata.
+	// With libvips < 8.10, removing any EXIF field removes all the EXIF metadata.
+	Keep KeepMetadata
+	// RemoveFields removes the given metadata fields, keyed by their libvips
+	// name such as "exif-ifd2-BodySerialNumber". With libvips < 8.10,
+	// removing an EXIF field removes all the EXIF metadata.
+	RemoveFields []string
+	// InputICCProfile and OutputICCProfile define the ICC profiles as bytes,
+	// taking precedence over InputICC and OutputICC.
+	InputICCProfile  []byte
+	OutputICCProfile []byte
+	// EmbedICCProfile embeds the given ICC profile without transforming
+	// the pixels. Ignored when StripMetadata or NoProfile are set.
+	EmbedICCProfile []byte
+	// KeepInterpretation keeps the sRGB, RGB16, B_W or GREY16 interpretation
+	// of the source image instead of converting it to Interpretation.
+	KeepInterpretation bool
+	// KeepCMYK keeps the CMYK colour space and profile of CMYK images saved
+	// as JPEG or TIFF. Otherwise, they are converted to sRGB using their
+	// embedded profile, InputICC or the built-in CMYK profile.
+	KeepCMYK bool
+	// DisplayP3 converts the image to the Display P3 colour space and embeds
+	// its profile, assuming sRGB if no profile is embedded (libvips 8.13+).
+	// Ignored when OutputICC is set.
+	DisplayP3 bool
+	// BitDepth defines the bits per sample of the output image, 8 or 16.
+	// 16 is only supported by PNG, TIFF and AVIF, which is saved with 12 bits
+	// (libvips 8.15+). Defaults to 16 for 16-bit sources when KeepInterpretation
+	// is set, 8 otherwise.
+	BitDepth int
+	// JPEG defines the advanced JPEG encoder options, such as JPEGPresetMozJPEG.
+	JPEG JPEGOptions
 
 	// private fields
 	autoRotateOnly bool
+	result         *SaveResult
 }
@@ -0,0 +1,462 @@
+package bimg
+
+/*
+#cgo pkg-config: vips
+#include "vips/vips.h"
+*/
+import "C"
+
+import (
+	"errors"
+	"math"
+	"runtime"
+	"strings"
+)
+
+const (
+	// blurHashSampleSize defines the maximum width and height of
+	// the downscaled image used to compute the BlurHash.
+	blurHashSampleSize = 32
+	// thumbHashSampleSize defines the maximum width and height of
+	// the downscaled image used to compute the ThumbHash.
+	thumbHashSampleSize = 100
+	// blurHashCharacters defines the base 83 alphabet used by BlurHash.
+	blurHashCharacters = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz#$%*+,-.:;=?@[]^_{|}~"
+)
+
+var (
+	// ErrBlurHashComponents is returned when the BlurHash components are out of the 1-9 range.
+	ErrBlurHashComponents = errors.New("blurhash components must be between 1 and 9")
+	// ErrBlurHashInvalid is returned when decoding a malformed BlurHash.
+	ErrBlurHashInvalid = errors.New("invalid blurhash")
+)
+
+// BlurHash returns the BlurHash placeholder of the image, using the given number
+// of horizontal and vertical components. See: https://blurha.sh
+// The image is auto rotated based on its EXIF orientation.
+func BlurHash(buf []byte, xComponents, yComponents int) (string, error) {
+	defer C.vips_thread_shutdown()
+	defer runtime.KeepAlive(buf)
+
+	if xComponents < 1 || xComponents > 9 || yComponents < 1 || yComponents > 9 {
+		return "", ErrBlurHashComponents
+	}
+
+	pixels, width, height, err := placeholderPixels(buf, blurHashSampleSize, false)
+	if err != nil {
+		return "", err
+	}
+
+	return encodeBlurHash(pixels, width, height, xComponents, yComponents), nil
+}
+
+// BlurHashImage decodes the BlurHash into an image of the given size and type.
+func BlurHashImage(hash string, width, height int, t ImageType) ([]byte, error) {
+	defer C.vips_thread_shutdown()
+
+	if width < 1 || height < 1 {
+		return nil, errors.New("invalid image size")
+	}
+	if t == UNKNOWN {
+		t = PNG
+	}
+	if !IsTypeSupportedSave(t) {
+		return nil, errors.New("Unsupported image output type")
+	}
+
+	pixels, err := decodeBlurHash(hash, width, height)
+	if err != nil {
+		return nil, err
+	}
+
+	image, err := vipsImageFromPixels(pixels, width, height, 3)
+	if err != nil {
+		return nil, err
+	}
+
+	return vipsSave(image, vipsSaveOptions{
+		Type:           t,
+		Quality:        Quality,
+		Compression:    6,
+		Interpretation: InterpretationSRGB,
+	})
+}
+
+// ThumbHash returns the ThumbHash placeholder of the image, which also encodes
+// its aspect ratio and alpha channel. See: https://evanw.github.io/thumbhash
+// The image is auto rotated based on its EXIF orientation.
+func ThumbHash(buf []byte) ([]byte, error) {
+	defer C.vips_thread_shutdown()
+	defer runtime.KeepAlive(buf)
+
+	pixels, width, height, err := placeholderPixels(buf, thumbHashSampleSize, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return encodeThumbHash(pixels, width, height), nil
+}
+
+// placeholderPixels returns the 8-bit sRGB pixels of the image, auto rotated
+// and scaled down to fit into size x size pixels. Transparent images are
+// flattened over white, unless alpha is requested, in which case the
+// pixels always have an alpha band.
+func placeholderPixels(buf []byte, size int, alpha bool) ([]byte, int, int, error) {
+	image, err := loadSample(buf, size, size)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	image, _, err = rotateAndFlipImage(image, Options{})
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	if !alpha {
+		image, err = vipsFlattenBackground(image, Color{255, 255, 255})
+		if err != nil {
+			return nil, 0, 0, err
+		}
+	}
+
+	image, err = vipsColourspace(image, InterpretationSRGB)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	image, err = vipsCastUchar(image)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	if alpha && !vipsHasAlpha(image) {
+		image, err = vipsAddAlpha(image)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+	}
+
+	scale := math.Min(1, math.Min(float64(size)/float64(image.Xsize), float64(size)/float64(image.Ysize)))
+	width := int(math.Max(1, float64(roundFloat(float64(image.Xsize)*scale))))
+	height := int(math.Max(1, float64(roundFloat(float64(image.Ysize)*scale))))
+
+	image, err = resizeImageExact(image, width, height)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	defer C.g_object_unref(C.gpointer(image))
+
+	pixels, err := vipsImagePixels(image)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	return pixels, width, height, nil
+}
+
+func encodeBlurHash(pixels []byte, width, height, xComponents, yComponents int) string {
+	factors := make([][3]float64, xComponents*yComponents)
+	for j := 0; j < yComponents; j++ {
+		for i := 0; i < xComponents; i++ {
+			normalisation := 2.0
+			if i == 0 && j == 0 {
+				normalisation = 1
+			}
+
+			var factor [3]float64
+			for y := 0; y < height; y++ {
+				basisY := math.Cos(math.Pi * float64(j) * float64(y) / float64(height))
+				for x := 0; x < width; x++ {
+					basis := basisY * math.Cos(math.Pi*float64(i)*float64(x)/float64(width))
+					p := (y*width + x) * 3
+					factor[0] += basis * srgbToLinear(pixels[p])
+					factor[1] += basis * srgbToLinear(pixels[p+1])
+					factor[2] += basis * srgbToLinear(pixels[p+2])
+				}
+			}
+
+			scale := normalisation / float64(width*height)
+			factors[j*xComponents+i] = [3]float64{factor[0] * scale, factor[1] * scale, factor[2] * scale}
+		}
+	}
+
+	var hash strings.Builder
+	hash.WriteString(encodeBase83((xComponents-1)+(yComponents-1)*9, 1))
+
+	maximumValue := 1.0
+	if len(factors) > 1 {
+		actualMaximumValue := 0.0
+		for _, factor := range factors[1:] {
+			for _, c := range factor {
+				actualMaximumValue = math.Max(actualMaximumValue, math.Abs(c))
+			}
+		}
+		quantisedMaximumValue := int(math.Max(0, math.Min(82, math.Floor(actualMaximumValue*166-0.5))))
+		maximumValue = float64(quantisedMaximumValue+1) / 166
+		hash.WriteString(encodeBase83(quantisedMaximumValue, 1))
+	} else {
+		hash.WriteString(encodeBase83(0, 1))
+	}
+
+	dc := factors[0]
+	hash.WriteString(encodeBase83(linearToSRGB(dc[0])<<16+linearToSRGB(dc[1])<<8+linearToSRGB(dc[2]), 4))
+
+	for _, factor := range factors[1:] {
+		var quant [3]int
+		for c := range factor {
+			quant[c] = int(math.Max(0, math.Min(18, math.Floor(signPow(factor[c]/maximumValue, 0.5)*9+9.5))))
+		}
+		hash.WriteString(encodeBase83(quant[0]*19*19+quant[1]*19+quant[2], 2))
+	}
+
+	return hash.String()
+}
+
+func decodeBlurHash(hash string, width, height int) ([]byte, error) {
+	if len(hash) < 6 {
+		return nil, ErrBlurHashInvalid
+	}
+
+	sizeFlag, err := decodeBase83(hash[:1])
+	if err != nil {
+		return nil, err
+	}
+	yComponents := sizeFlag/9 + 1
+	xComponents := sizeFlag%9 + 1
+	if len(hash) != 4+2*xComponents*yComponents {
+		return nil, ErrBlurHashInvalid
+	}
+
+	quantisedMaximumValue, err := decodeBase83(hash[1:2])
+	if err != nil {
+		return nil, err
+	}
+	maximumValue := float64(quantisedMaximumValue+1) / 166
+
+	colors := make([][3]float64, xComponents*yComponents)
+	for i := range colors {
+		if i == 0 {
+			value, err := decodeBase83(hash[2:6])
+			if err != nil {
+				return nil, err
+			}
+			colors[i] = [3]float64{
+				srgbToLinear(byte(value >> 16)),
+				srgbToLinear(byte(value >> 8 & 255)),
+				srgbToLinear(byte(value & 255)),
+			}
+			continue
+		}
+
+		value, err := decodeBase83(hash[4+i*2 : 6+i*2])
+		if err != nil {
+			return nil, err
+		}
+		colors[i] = [3]float64{
+			signPow((float64(value/(19*19))-9)/9, 2) * maximumValue,
+			signPow((float64(value/19%19)-9)/9, 2) * maximumValue,
+			signPow((float64(value%19)-9)/9, 2) * maximumValue,
+		}
+	}
+
+	pixels := make([]byte, width*height*3)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			var color [3]float64
+			for j := 0; j < yComponents; j++ {
+				basisY := math.Cos(math.Pi * float64(y) * float64(j) / float64(height))
+				for i := 0; i < xComponents; i++ {
+					basis := basisY * math.Cos(math.Pi*float64(x)*float64(i)/float64(width))
+					c := colors[i+j*xComponents]
+					color[0] += c[0] * basis
+					color[1] += c[1] * basis
+					color[2] += c[2] * basis
+				}
+			}
+
+			p := (y*width + x) * 3
+			pixels[p] = byte(linearToSRGB(color[0]))
+			pixels[p+1] = byte(linearToSRGB(color[1]))
+			pixels[p+2] = byte(linearToSRGB(color[2]))
+		}
+	}
+
+	return pixels, nil
+}
+
+func encodeThumbHash(pixels []byte, width, height int) []byte {
+	// Average colour, weighted by the alpha channel
+	var avgR, avgG, avgB, avgA float64
+	for i := 0; i < width*height; i++ {
+		alpha := float64(pixels[i*4+3]) / 255
+		avgR += alpha / 255 * float64(pixels[i*4])
+		avgG += alpha / 255 * float64(pixels[i*4+1])
+		avgB += alpha / 255 * float64(pixels[i*4+2])
+		avgA += alpha
+	}
+	if avgA > 0 {
+		avgR /= avgA
+		avgG /= avgA
+		avgB /= avgA
+	}
+
+	hasAlpha := avgA < float64(width*height)
+	lLimit := 7.0
+	if hasAlpha {
+		// Use fewer luminance bits if there's alpha
+		lLimit = 5
+	}
+	longest := math.Max(float64(width), float64(height))
+	lx := int(math.Max(1, jsRound(lLimit*float64(width)/longest)))
+	ly := int(math.Max(1, jsRound(lLimit*float64(height)/longest)))
+
+	// Convert to luminance, yellow-blue, red-green and alpha
+	// channels, composited atop the average colour
+	l := make([]float64, width*height)
+	p := make([]float64, width*height)
+	q := make([]float64, width*height)
+	a := make([]float64, width*height)
+	for i := range l {
+		alpha := float64(pixels[i*4+3]) / 255
+		r := avgR*(1-alpha) + alpha/255*float64(pixels[i*4])
+		g := avgG*(1-alpha) + alpha/255*float64(pixels[i*4+1])
+		b := avgB*(1-alpha) + alpha/255*float64(pixels[i*4+2])
+		l[i] = (r + g + b) / 3
+		p[i] = (r+g)/2 - b
+		q[i] = r - g
+		a[i] = alpha
+	}
+
+	lDC, lAC, lScale := thumbHashChannel(l, width, height, maxInt(3, lx), maxInt(3, ly))
+	pDC, pAC, pScale := thumbHashChannel(p, width, height, 3, 3)
+	qDC, qAC, qScale := thumbHashChannel(q, width, height, 3, 3)
+
+	isLandscape := width > height
+	header24 := int(jsRound(63*lDC)) | int(jsRound(31.5+31.5*pDC))<<6 | int(jsRound(31.5+31.5*qDC))<<12 | int(jsRound(31*lScale))<<18
+	header16 := int(jsRound(63*pScale))<<3 | int(jsRound(63*qScale))<<9
+	if isLandscape {
+		header16 |= ly | 1<<15
+	} else {
+		header16 |= lx
+	}
+
+	acs := [][]float64{lAC, pAC, qAC}
+	if hasAlpha {
+		header24 |= 1 << 23
+	}
+	hash := []byte{byte(header24), byte(header24 >> 8), byte(header24 >> 16), byte(header16), byte(header16 >> 8)}
+	if hasAlpha {
+		aDC, aAC, aScale := thumbHashChannel(a, width, height, 5, 5)
+		hash = append(hash, byte(int(jsRound(15*aDC))|int(jsRound(15*aScale))<<4))
+		acs = append(acs, aAC)
+	}
+
+	// Pack the normalized varying factors as nibbles
+	acStart := len(hash)
+	acIndex := 0
+	for _, ac := range acs {
+		for _, f := range ac {
+			i := acStart + acIndex>>1
+			if i == len(hash) {
+				hash = append(hash, 0)
+			}
+			hash[i] |= byte(int(jsRound(15*f)) << uint((acIndex&1)<<2))
+			acIndex++
+		}
+	}
+
+	return hash
+}
+
+// thumbHashChannel encodes the channel using the DCT into the constant term,
+// the normalized varying terms and their scale.
+func thumbHashChannel(channel []float64, width, height, nx, ny int) (float64, []float64, float64) {
+	var dc, scale float64
+	var ac []float64
+	fx := make([]float64, width)
+
+	for cy := 0; cy < ny; cy++ {
+		for cx := 0; cx*ny < nx*(ny-cy); cx++ {
+			for x := 0; x < width; x++ {
+				fx[x] = math.Cos(math.Pi / float64(width) * float64(cx) * (float64(x) + 0.5))
+			}
+
+			f := 0.0
+			for y := 0; y < height; y++ {
+				fy := math.Cos(math.Pi / float64(height) * float64(cy) * (float64(y) + 0.5))
+				for x := 0; x < width; x++ {
+					f += channel[x+y*width] * fx[x] * fy
+				}
+			}
+			f /= float64(width * height)
+
+			if cx > 0 || cy > 0 {
+				ac = append(ac, f)
+				scale = math.Max(scale, math.Abs(f))
+			} else {
+				dc = f
+			}
+		}
+	}
+
+	if scale > 0 {
+		for i := range ac {
+			ac[i] = 0.5 + 0.5/scale*ac[i]
+		}
+	}
+
+	return dc, ac, scale
+}
+
+func encodeBase83(value, length int) string {
+	result := make([]byte, length)
+	for i := 1; i <= length; i++ {
+		digit := value / int(math.Pow(83, float64(length-i))) % 83
+		result[i-1] = blurHashCharacters[digit]
+	}
+	return string(result)
+}
+
+func decodeBase83(s string) (int, error) {
+	value := 0
+	for _, c := range s {
+		digit := strings.IndexRune(blurHashCharacters, c)
+		if digit < 0 {
+			return 0, ErrBlurHashInvalid
+		}
+		value = value*83 + digit
+	}
+	return value, nil
+}
+
+func srgbToLinear(value byte) float64 {
+	v := float64(value) / 255
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+func linearToSRGB(value float64) int {
+	v := math.Max(0, math.Min(1, value))
+	if v <= 0.0031308 {
+		return int(v*12.92*255 + 0.5)
+	}
+	return int((1.055*math.Pow(v, 1/2.4)-0.055)*255 + 0.5)
+}
+
+func signPow(value, exp float64) float64 {
+	return math.Copysign(math.Pow(math.Abs(value), exp), value)
+}
+
+// jsRound rounds half up, as the ThumbHash reference implementation does.
+func jsRound(value float64) float64 {
+	return math.Floor(value + 0.5)
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
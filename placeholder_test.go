@@ -0,0 +1,106 @@
+package bimg
+
+import (
+	"testing"
+)
+
+func TestBlurHash(t *testing.T) {
+	buf, _ := Read("testdata/test.jpg")
+
+	hash, err := BlurHash(buf, 4, 3)
+	if err != nil {
+		t.Fatalf("Cannot compute the blurhash: %s", err)
+	}
+	if len(hash) != 4+2*4*3 {
+		t.Fatalf("Unexpected blurhash length: %s", hash)
+	}
+
+	img, err := BlurHashImage(hash, 32, 20, JPEG)
+	if err != nil {
+		t.Fatalf("Cannot decode the blurhash: %s", err)
+	}
+	if DetermineImageType(img) != JPEG {
+		t.Fatal("Image is not jpeg")
+	}
+
+	err = assertSize(img, 32, 20)
+	if err != nil {
+		t.Error(err)
+	}
+
+	Write("testdata/test_blurhash_out.jpg", img)
+}
+
+func TestBlurHashOrientation(t *testing.T) {
+	// Landscape_1 has no rotation, while Landscape_6 must be auto rotated
+	// to look the same, so both should produce the same placeholder
+	upright, err := BlurHash(readImage("exif/Landscape_1.jpg"), 4, 3)
+	if err != nil {
+		t.Fatalf("Cannot compute the blurhash: %s", err)
+	}
+	rotated, err := BlurHash(readImage("exif/Landscape_6.jpg"), 4, 3)
+	if err != nil {
+		t.Fatalf("Cannot compute the blurhash: %s", err)
+	}
+
+	if upright[:6] != rotated[:6] {
+		t.Fatalf("Unexpected blurhash for the rotated image: %s != %s", rotated, upright)
+	}
+}
+
+func TestBlurHashInvalid(t *testing.T) {
+	buf, _ := Read("testdata/test.jpg")
+
+	if _, err := BlurHash(buf, 0, 3); err != ErrBlurHashComponents {
+		t.Fatalf("Expected ErrBlurHashComponents, got: %v", err)
+	}
+	if _, err := BlurHashImage("LEHV6nWB2yk8", 32, 32, PNG); err != ErrBlurHashInvalid {
+		t.Fatalf("Expected ErrBlurHashInvalid, got: %v", err)
+	}
+}
+
+func TestBlurHashRoundTrip(t *testing.T) {
+	// Horizontal gradient from black to white
+	width, height := 16, 8
+	pixels := make([]byte, width*height*3)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			v := byte(x * 255 / (width - 1))
+			p := (y*width + x) * 3
+			pixels[p], pixels[p+1], pixels[p+2] = v, v, v
+		}
+	}
+
+	hash := encodeBlurHash(pixels, width, height, 4, 1)
+	decoded, err := decodeBlurHash(hash, width, height)
+	if err != nil {
+		t.Fatalf("Cannot decode the blurhash %s: %s", hash, err)
+	}
+
+	if decoded[0] >= decoded[(width-1)*3] {
+		t.Fatalf("Gradient not preserved: %d >= %d", decoded[0], decoded[(width-1)*3])
+	}
+}
+
+func TestThumbHash(t *testing.T) {
+	files := []struct {
+		name  string
+		alpha bool
+	}{
+		{"test.jpg", false},
+		{"transparent.png", true},
+	}
+
+	for _, file := range files {
+		hash, err := ThumbHash(readImage(file.name))
+		if err != nil {
+			t.Fatalf("Cannot compute the thumbhash of %s: %s", file.name, err)
+		}
+		if len(hash) < 5 || len(hash) > 25 {
+			t.Fatalf("Unexpected thumbhash length for %s: %d", file.name, len(hash))
+		}
+		if alpha := hash[2]&0x80 != 0; alpha != file.alpha {
+			t.Fatalf("Unexpected thumbhash alpha flag for %s: %t", file.name, alpha)
+		}
+	}
+}
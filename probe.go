@@ -0,0 +1,67 @@
+package bimg
+
+/*
+#cgo pkg-config: vips
+#include "vips/vips.h"
+*/
+import "C"
+
+import (
+	"runtime"
+)
+
+// ImageProbe represents the image header fields, read without decoding the pixels.
+type ImageProbe struct {
+	Type     string
+	Size     ImageSize
+	Channels int
+	// Orientation is the EXIF orientation, 0 if undefined.
+	Orientation int
+	// DisplaySize is the size of the image once auto-rotated
+	// according to its EXIF orientation.
+	DisplaySize ImageSize
+	// Pages is the number of pages or animation frames. Size is
+	// the size of a single page.
+	Pages    int
+	Animated bool
+}
+
+// Probe returns the image header fields. Unlike Size and Metadata it opens
+// the image for sequential access, so that no pixel is decoded or cached,
+// which is much cheaper for large images.
+func Probe(buf []byte) (ImageProbe, error) {
+	defer C.vips_thread_shutdown()
+	defer runtime.KeepAlive(buf)
+
+	image, imageType, err := vipsReadHeader(buf)
+	if err != nil {
+		return ImageProbe{}, err
+	}
+	defer C.g_object_unref(C.gpointer(image))
+
+	size := ImageSize{
+		Width:  int(image.Xsize),
+		Height: int(image.Ysize),
+	}
+	orientation := vipsExifIntTag(image, Orientation)
+	pages := vipsPages(image)
+
+	return ImageProbe{
+		Type:        ImageTypeName(imageType),
+		Size:        size,
+		Channels:    int(image.Bands),
+		Orientation: orientation,
+		DisplaySize: displaySize(size, orientation),
+		Pages:       pages,
+		Animated:    pages > 1 && (imageType == GIF || imageType == WEBP),
+	}, nil
+}
+
+// displaySize returns the image size once rotated according
+// to the EXIF orientation, which is transposed for 5 to 8.
+func displaySize(size ImageSize, orientation int) ImageSize {
+	if orientation >= 5 && orientation <= 8 {
+		return ImageSize{Width: size.Height, Height: size.Width}
+	}
+	return size
+}
@@ -0,0 +1,100 @@
+package bimg
+
+import (
+	"testing"
+)
+
+func TestProbe(t *testing.T) {
+	files := []struct {
+		name        string
+		format      string
+		size        ImageSize
+		displaySize ImageSize
+		orientation int
+	}{
+		{"test.jpg", "jpeg", ImageSize{1680, 1050}, ImageSize{1680, 1050}, 0},
+		{"test.png", "png", ImageSize{400, 300}, ImageSize{400, 300}, 0},
+		{"test.webp", "webp", ImageSize{550, 368}, ImageSize{550, 368}, 0},
+		{"exif/Landscape_1.jpg", "jpeg", ImageSize{1600, 1200}, ImageSize{1600, 1200}, 1},
+		{"exif/Landscape_6.jpg", "jpeg", ImageSize{1200, 1600}, ImageSize{1600, 1200}, 6},
+	}
+
+	for _, file := range files {
+		probe, err := Probe(readFile(file.name))
+		if err != nil {
+			t.Fatalf("Cannot probe the image: %s -> %s", file.name, err)
+		}
+		if probe.Type != file.format {
+			t.Fatalf("Unexpected image format: %s != %s", probe.Type, file.format)
+		}
+		if probe.Size != file.size {
+			t.Fatalf("Unexpected image size: %#v != %#v", probe.Size, file.size)
+		}
+		if probe.DisplaySize != file.displaySize {
+			t.Fatalf("Unexpected image display size: %#v != %#v", probe.DisplaySize, file.displaySize)
+		}
+		if probe.Orientation != file.orientation {
+			t.Fatalf("Unexpected image orientation: %d != %d", probe.Orientation, file.orientation)
+		}
+		if probe.Pages != 1 || probe.Animated {
+			t.Fatalf("Unexpected image pages: %d", probe.Pages)
+		}
+	}
+}
+
+func TestProbeAnimated(t *testing.T) {
+	if VipsMajorVersion <= 8 && VipsMinorVersion < 8 {
+		t.Skip("Skip test in libvips < 8.8")
+		return
+	}
+
+	probe, err := Probe(readFile("test.gif"))
+	if err != nil {
+		t.Fatalf("Cannot probe the image: %s", err)
+	}
+	if probe.Pages <= 1 || !probe.Animated {
+		t.Fatalf("Expected an animated image: %#v", probe)
+	}
+}
+
+func TestProbeInvalid(t *testing.T) {
+	if _, err := Probe([]byte("not an image")); err == nil {
+		t.Fatal("Expected an error probing an invalid image")
+	}
+}
+
+func BenchmarkSizeLargeJpeg(b *testing.B) {
+	buf := readFile("test.jpg")
+	for n := 0; n < b.N; n++ {
+		if _, err := Size(buf); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkProbeLargeJpeg(b *testing.B) {
+	buf := readFile("test.jpg")
+	for n := 0; n < b.N; n++ {
+		if _, err := Probe(buf); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSizePng(b *testing.B) {
+	buf := readFile("test.png")
+	for n := 0; n < b.N; n++ {
+		if _, err := Size(buf); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkProbePng(b *testing.B) {
+	buf := readFile("test.png")
+	for n := 0; n < b.N; n++ {
+		if _, err := Probe(buf); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
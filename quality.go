@@ -0,0 +1,271 @@
+package bimg
+
+/*
+#cgo pkg-config: vips
+#include "vips/vips.h"
+*/
+import "C"
+
+import (
+	"errors"
+	"math"
+	"runtime"
+)
+
+const (
+	// minQuality defines the lowest quality tried when searching for
+	// the encoding quality of an image.
+	minQuality = 1
+	// maxQuality defines the highest quality tried when searching for
+	// the encoding quality of an image.
+	maxQuality = 100
+	// maxBytesResizeAttempts defines how many times the image dimensions
+	// are reduced when it does not fit into Options.MaxBytes.
+	maxBytesResizeAttempts = 6
+)
+
+var (
+	// ErrMaxBytesExceeded is returned when the image cannot be encoded
+	// within the size defined by Options.MaxBytes.
+	ErrMaxBytesExceeded = errors.New("cannot encode the image within the given max bytes")
+	// ErrTargetQualityRange is returned when Options.TargetQuality is not between 0 and 1.
+	ErrTargetQualityRange = errors.New("target quality must be between 0 and 1")
+)
+
+// SaveResult represents the encoding parameters chosen when saving an image.
+// SSIM is only reported when the quality was picked by Options.TargetQuality,
+// including when Options.MaxBytes lowered it further.
+type SaveResult struct {
+	Quality int
+	Width   int
+	Height  int
+	SSIM    float64
+}
+
+// qualityIsAdjustable reports whether the output size of the given save
+// options depends on the encoding quality.
+func qualityIsAdjustable(o vipsSaveOptions) bool {
+	switch o.Type {
+	case JPEG:
+		return true
+	case WEBP, HEIF, AVIF:
+		return !o.Lossless
+	case PNG:
+		return o.Palette
+	}
+	return false
+}
+
+// vipsSaveCopy encodes the image like vipsSave does, but without
+// releasing it, so it can be encoded again.
+func vipsSaveCopy(image *C.VipsImage, o vipsSaveOptions) ([]byte, error) {
+	C.g_object_ref(C.gpointer(image))
+	return vipsSave(image, o)
+}
+
+// saveImageMaxBytes encodes the image at decreasing quality until the output
+// fits into o.MaxBytes. If the lowest quality does not fit either and
+// o.MaxBytesResize is enabled, the image is scaled down and the search
+// starts again.
+func saveImageMaxBytes(image *C.VipsImage, o Options, saveOptions vipsSaveOptions) ([]byte, error) {
+	// Render the image once, so every encoding attempt reuses the
+	// processed pixels instead of evaluating the whole pipeline again
+	image, err := vipsCopyMemory(image)
+	if err != nil {
+		return nil, err
+	}
+
+	return encodeImageMaxBytes(image, o, saveOptions, false)
+}
+
+// encodeImageMaxBytes runs the max bytes search of saveImageMaxBytes on an
+// image already copied to memory, releasing it. When measureSSIM is set, the
+// structural similarity of the output is reported in the SaveResult.
+func encodeImageMaxBytes(image *C.VipsImage, o Options, saveOptions vipsSaveOptions, measureSSIM bool) ([]byte, error) {
+	for attempt := 0; ; attempt++ {
+		buf, quality, err := encodeMaxBytes(image, saveOptions, o.MaxBytes)
+		if err != nil {
+			C.g_object_unref(C.gpointer(image))
+			return nil, err
+		}
+
+		if len(buf) <= o.MaxBytes {
+			result := SaveResult{Quality: quality, Width: int(image.Xsize), Height: int(image.Ysize)}
+			if measureSSIM {
+				result.SSIM, err = encodedSSIM(image, buf)
+				if err != nil {
+					C.g_object_unref(C.gpointer(image))
+					return nil, err
+				}
+			}
+			o.setResult(result)
+			C.g_object_unref(C.gpointer(image))
+			return buf, nil
+		}
+
+		if !o.MaxBytesResize || attempt == maxBytesResizeAttempts {
+			C.g_object_unref(C.gpointer(image))
+			return nil, ErrMaxBytesExceeded
+		}
+
+		// The encoded size grows roughly with the number of pixels
+		scale := math.Sqrt(float64(o.MaxBytes)/float64(len(buf))) * 0.9
+		if int(float64(image.Xsize)*scale) < 1 || int(float64(image.Ysize)*scale) < 1 {
+			C.g_object_unref(C.gpointer(image))
+			return nil, ErrMaxBytesExceeded
+		}
+
+		// vipsReduce releases the previous image
+		image, err = vipsReduce(image, 1/scale, 1/scale)
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// encodeMaxBytes binary searches the highest quality, up to o.Quality, whose
+// encoded output fits into maxBytes. When none fits, the smallest output
+// is returned along with the quality used to produce it.
+func encodeMaxBytes(image *C.VipsImage, o vipsSaveOptions, maxBytes int) ([]byte, int, error) {
+	buf, err := vipsSaveCopy(image, o)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(buf) <= maxBytes || !qualityIsAdjustable(o) {
+		return buf, o.Quality, nil
+	}
+
+	smallest, smallestQuality := buf, o.Quality
+	var best []byte
+	bestQuality := 0
+
+	low, high := minQuality, o.Quality-1
+	for low <= high {
+		quality := (low + high) / 2
+		o.Quality = quality
+
+		buf, err = vipsSaveCopy(image, o)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		if len(buf) <= maxBytes {
+			best, bestQuality = buf, quality
+			low = quality + 1
+			continue
+		}
+
+		if len(buf) < len(smallest) {
+			smallest, smallestQuality = buf, quality
+		}
+		high = quality - 1
+	}
+
+	if best != nil {
+		return best, bestQuality, nil
+	}
+	return smallest, smallestQuality, nil
+}
+
+// saveImageTargetQuality encodes the image at the lowest quality whose output
+// keeps a structural similarity of at least o.TargetQuality with the image.
+// When o.MaxBytes is also defined, the chosen quality becomes the upper
+// bound of the max bytes search.
+func saveImageTargetQuality(image *C.VipsImage, o Options, saveOptions vipsSaveOptions) ([]byte, error) {
+	// Render the image once, so every encoding attempt reuses the
+	// processed pixels instead of evaluating the whole pipeline again
+	image, err := vipsCopyMemory(image)
+	if err != nil {
+		return nil, err
+	}
+
+	buf, quality, ssim, err := encodeTargetQuality(image, saveOptions, o.TargetQuality)
+	if err != nil {
+		C.g_object_unref(C.gpointer(image))
+		return nil, err
+	}
+
+	// Search for a lower quality which fits into the max bytes,
+	// reusing the image already copied to memory
+	if o.MaxBytes > 0 && len(buf) > o.MaxBytes {
+		saveOptions.Quality = quality
+		return encodeImageMaxBytes(image, o, saveOptions, true)
+	}
+
+	o.setResult(SaveResult{Quality: quality, Width: int(image.Xsize), Height: int(image.Ysize), SSIM: ssim})
+	C.g_object_unref(C.gpointer(image))
+	return buf, nil
+}
+
+// encodeTargetQuality binary searches the lowest quality whose encoded output
+// keeps a structural similarity of at least target with the image. When none
+// does, the output encoded at the highest quality is returned.
+func encodeTargetQuality(image *C.VipsImage, o vipsSaveOptions, target float64) ([]byte, int, float64, error) {
+	var best, highest []byte
+	bestQuality, bestSSIM := 0, 0.0
+	highestQuality, highestSSIM := 0, 0.0
+
+	low, high := minQuality, maxQuality
+	for low <= high {
+		quality := (low + high) / 2
+		o.Quality = quality
+
+		buf, ssim, err := encodeSSIM(image, o)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+
+		if quality > highestQuality {
+			highest, highestQuality, highestSSIM = buf, quality, ssim
+		}
+
+		if ssim >= target {
+			best, bestQuality, bestSSIM = buf, quality, ssim
+			high = quality - 1
+			continue
+		}
+		low = quality + 1
+	}
+
+	if best != nil {
+		return best, bestQuality, bestSSIM, nil
+	}
+	return highest, highestQuality, highestSSIM, nil
+}
+
+// encodeSSIM encodes the image and measures the structural
+// similarity of the decoded output with it.
+func encodeSSIM(image *C.VipsImage, o vipsSaveOptions) ([]byte, float64, error) {
+	buf, err := vipsSaveCopy(image, o)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	ssim, err := encodedSSIM(image, buf)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return buf, ssim, nil
+}
+
+// encodedSSIM measures the structural similarity of the
+// decoded buffer with the image it was encoded from.
+func encodedSSIM(image *C.VipsImage, buf []byte) (float64, error) {
+	decoded, _, err := vipsRead(buf)
+	if err != nil {
+		return 0, err
+	}
+	defer C.g_object_unref(C.gpointer(decoded))
+
+	ssim, err := vipsSSIM(image, decoded)
+	runtime.KeepAlive(buf)
+	return ssim, err
+}
+
+// setResult reports the encoding parameters to the caller, if requested.
+func (o Options) setResult(r SaveResult) {
+	if o.result != nil {
+		*o.result = r
+	}
+}
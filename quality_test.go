@@ -0,0 +1,138 @@
+package bimg
+
+import (
+	"testing"
+)
+
+func TestResizeMaxBytes(t *testing.T) {
+	options := Options{Width: 800, Height: 600, MaxBytes: 30 * 1024}
+	buf, _ := Read("testdata/test.jpg")
+
+	newImg, result, err := ResizeWithResult(buf, options)
+	if err != nil {
+		t.Fatalf("Resize(imgData, %#v) error: %#v", options, err)
+	}
+
+	if len(newImg) > options.MaxBytes {
+		t.Fatalf("Image exceeds the max bytes: %d > %d", len(newImg), options.MaxBytes)
+	}
+	if result.Quality < minQuality || result.Quality > Quality {
+		t.Fatalf("Invalid chosen quality: %d", result.Quality)
+	}
+
+	size, _ := Size(newImg)
+	if size.Height != options.Height || size.Width != options.Width {
+		t.Fatalf("Invalid image size: %dx%d", size.Width, size.Height)
+	}
+
+	Write("testdata/test_max_bytes_out.jpg", newImg)
+}
+
+func TestResizeMaxBytesResize(t *testing.T) {
+	options := Options{Width: 800, Height: 600, MaxBytes: 2 * 1024, MaxBytesResize: true}
+	buf, _ := Read("testdata/test.jpg")
+
+	newImg, result, err := ResizeWithResult(buf, options)
+	if err != nil {
+		t.Fatalf("Resize(imgData, %#v) error: %#v", options, err)
+	}
+
+	if len(newImg) > options.MaxBytes {
+		t.Fatalf("Image exceeds the max bytes: %d > %d", len(newImg), options.MaxBytes)
+	}
+
+	size, _ := Size(newImg)
+	if size.Width >= options.Width || size.Height >= options.Height {
+		t.Fatalf("Image should have been scaled down: %dx%d", size.Width, size.Height)
+	}
+	if size.Width != result.Width || size.Height != result.Height {
+		t.Fatalf("Invalid reported size: %dx%d", result.Width, result.Height)
+	}
+}
+
+func TestResizeMaxBytesExceeded(t *testing.T) {
+	options := Options{Width: 800, Height: 600, MaxBytes: 100}
+	buf, _ := Read("testdata/test.jpg")
+
+	_, err := Resize(buf, options)
+	if err != ErrMaxBytesExceeded {
+		t.Fatalf("Expected ErrMaxBytesExceeded, got: %v", err)
+	}
+}
+
+func TestResizeWithResultQuality(t *testing.T) {
+	options := Options{Width: 300, Height: 200, Quality: 90}
+	buf, _ := Read("testdata/test.jpg")
+
+	_, result, err := ResizeWithResult(buf, options)
+	if err != nil {
+		t.Fatalf("Resize(imgData, %#v) error: %#v", options, err)
+	}
+	if result.Quality != options.Quality {
+		t.Fatalf("Invalid reported quality: %d != %d", result.Quality, options.Quality)
+	}
+}
+
+func TestResizeTargetQuality(t *testing.T) {
+	buf, _ := Read("testdata/test.jpg")
+
+	for _, typ := range []ImageType{JPEG, WEBP, AVIF} {
+		if !IsTypeSupportedSave(typ) {
+			continue
+		}
+
+		options := Options{Width: 800, Height: 600, Type: typ, TargetQuality: 0.95}
+		newImg, result, err := ResizeWithResult(buf, options)
+		if err != nil {
+			t.Fatalf("Resize(imgData, %#v) error: %#v", options, err)
+		}
+
+		if DetermineImageType(newImg) != typ {
+			t.Fatalf("Image is not %s", ImageTypes[typ])
+		}
+		if result.SSIM < options.TargetQuality {
+			t.Fatalf("Invalid SSIM for %s: %f < %f", ImageTypes[typ], result.SSIM, options.TargetQuality)
+		}
+		if result.Quality < minQuality || result.Quality > maxQuality {
+			t.Fatalf("Invalid chosen quality for %s: %d", ImageTypes[typ], result.Quality)
+		}
+	}
+}
+
+func TestResizeTargetQualityLowerIsSmaller(t *testing.T) {
+	buf, _ := Read("testdata/test.jpg")
+
+	high, _ := Resize(buf, Options{Width: 800, Height: 600, TargetQuality: 0.99})
+	low, _ := Resize(buf, Options{Width: 800, Height: 600, TargetQuality: 0.90})
+
+	if len(low) == 0 || len(low) > len(high) {
+		t.Fatalf("Lower target quality should produce a smaller image: %d > %d", len(low), len(high))
+	}
+}
+
+func TestResizeTargetQualityMaxBytes(t *testing.T) {
+	buf, _ := Read("testdata/test.jpg")
+	options := Options{Width: 800, Height: 600, TargetQuality: 0.99, MaxBytes: 20 * 1024}
+
+	newImg, result, err := ResizeWithResult(buf, options)
+	if err != nil {
+		t.Fatalf("Cannot encode the image: %s", err)
+	}
+	if len(newImg) > options.MaxBytes {
+		t.Fatalf("The image exceeds the max bytes: %d > %d", len(newImg), options.MaxBytes)
+	}
+	if result.SSIM <= 0 || result.SSIM >= options.TargetQuality {
+		t.Fatalf("Expected the measured SSIM below the target: %f", result.SSIM)
+	}
+}
+
+func TestResizeTargetQualityRange(t *testing.T) {
+	buf, _ := Read("testdata/test.jpg")
+
+	for _, target := range []float64{-0.1, 1.5} {
+		_, err := Resize(buf, Options{Width: 300, TargetQuality: target})
+		if err != ErrTargetQualityRange {
+			t.Fatalf("Expected ErrTargetQualityRange for %f, got %v", target, err)
+		}
+	}
+}
@@ -14,3 +14,14 @@ func Resize(buf []byte, o Options) ([]byte, error) {
 	defer runtime.KeepAlive(buf)
 	return resizer(buf, o)
 }
+
+// ResizeWithResult is used to transform a given image as byte buffer
+// with the passed options, reporting the encoding parameters chosen
+// when saving it, such as the quality picked to fit into Options.MaxBytes.
+func ResizeWithResult(buf []byte, o Options) ([]byte, SaveResult, error) {
+	defer runtime.KeepAlive(buf)
+	var result SaveResult
+	o.result = &result
+	image, err := resizer(buf, o)
+	return image, result, err
+}
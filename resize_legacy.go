@@ -8,3 +8,14 @@ package bimg
 func Resize(buf []byte, o Options) ([]byte, error) {
 	return resizer(buf, o)
 }
+
+// ResizeWithResult is used to transform a given image as byte buffer
+// with the passed options, reporting the encoding parameters chosen
+// when saving it, such as the quality picked to fit into Options.MaxBytes.
+// Used as proxy to resizer() only in Go <= 1.6 versions
+func ResizeWithResult(buf []byte, o Options) ([]byte, SaveResult, error) {
+	var result SaveResult
+	o.result = &result
+	image, err := resizer(buf, o)
+	return image, result, err
+}
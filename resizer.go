@@ -15,6 +15,8 @@ import (
 var (
 	// ErrExtractAreaParamsRequired defines a generic extract area error
 	ErrExtractAreaParamsRequired = errors.New("extract area width/height params are required")
+	// ErrConvolutionKernel defines an invalid convolution kernel error
+	ErrConvolutionKernel = errors.New("convolution kernel must have width x height values")
 )
 
 // resizer is used to transform a given image as byte buffer
@@ -22,6 +24,10 @@ var (
 func resizer(buf []byte, o Options) ([]byte, error) {
 	defer C.vips_thread_shutdown()
 
+	if o.TargetQuality < 0 || o.TargetQuality > 1 {
+		return nil, ErrTargetQualityRange
+	}
+
 	image, imageType, err := loadImage(buf)
 	if err != nil {
 		return nil, err
@@ -82,9 +88,7 @@ func resizer(buf []byte, o Options) ([]byte, error) {
 	}
 
 	// Try to use libjpeg/libwebp shrink-on-load
-	supportsShrinkOnLoad := imageType == WEBP && VipsMajorVersion >= 8 && VipsMinorVersion >= 3
-	supportsShrinkOnLoad = supportsShrinkOnLoad || imageType == JPEG
-	if supportsShrinkOnLoad && shrink >= 2 {
+	if supportsShrinkOnLoad(imageType) && shrink >= 2 {
 		tmpImage, factor, err := shrinkOnLoad(buf, image, imageType, factor, shrink)
 		if err != nil {
 			return nil, err
@@ -96,6 +100,12 @@ func resizer(buf []byte, o Options) ([]byte, error) {
 		residual = float64(shrink) / factor
 	}
 
+	// Redact regions, if necessary
+	image, err = redactImage(image, o.Redact, inWidth, inHeight)
+	if err != nil {
+		return nil, err
+	}
+
 	// Zoom image, if necessary
 	image, err = zoomImage(image, o.Zoom)
 	if err != nil {
@@ -118,6 +128,24 @@ func resizer(buf []byte, o Options) ([]byte, error) {
 		}
 	}
 
+	// Adjust brightness, contrast, saturation and hue, if necessary
+	image, err = applyModulate(image, o)
+	if err != nil {
+		return nil, err
+	}
+
+	// Apply colour filters, if necessary
+	image, err = applyColourFilters(image, o)
+	if err != nil {
+		return nil, err
+	}
+
+	// Remove uniform background, if necessary
+	image, err = removeBackground(image, o)
+	if err != nil {
+		return nil, err
+	}
+
 	// Add watermark, if necessary
 	image, err = watermarkImageWithText(image, o.Watermark)
 	if err != nil {
@@ -130,6 +158,18 @@ func resizer(buf []byte, o Options) ([]byte, error) {
 		return nil, err
 	}
 
+	// Apply the transparency mask, if necessary
+	image, err = maskImage(image, o.Mask)
+	if err != nil {
+		return nil, err
+	}
+
+	// Add border, shadow and padding, if necessary
+	image, err = layoutImage(image, o)
+	if err != nil {
+		return nil, err
+	}
+
 	// Flatten image on a background, if necessary
 	image, err = imageFlatten(image, imageType, o)
 	if err != nil {
@@ -168,6 +208,12 @@ func applyDefaults(o Options, imageType ImageType) Options {
 	if o.Type == 0 {
 		o.Type = imageType
 	}
+	// Keep the transparent areas of the mask, the shadow and the removed
+	// background, unless a background colour to flatten them is defined
+	transparent := !o.Mask.isEmpty() || o.Shadow != (Shadow{})
+	if o.Type == JPEG && (o.RemoveBackground || transparent && o.Background == ColorBlack) {
+		o.Type = PNG
+	}
 	if o.Interpretation == 0 {
 		o.Interpretation = InterpretationSRGB
 	}
@@ -179,6 +225,12 @@ func applyDefaults(o Options, imageType ImageType) Options {
 }
 
 func saveImage(image *C.VipsImage, o Options) ([]byte, error) {
+	// Write the requested metadata, if necessary
+	image, err := writeMetadata(image, o)
+	if err != nil {
+		return nil, err
+	}
+
 	saveOptions := vipsSaveOptions{
 		Quality:        o.Quality,
 		Type:           o.Type,
@@ -188,13 +240,45 @@ func saveImage(image *C.VipsImage, o Options) ([]byte, error) {
 		Interpretation: o.Interpretation,
 		InputICC:       o.InputICC,
 		OutputICC:      o.OutputICC,
-		StripMetadata:  o.StripMetadata,
+		StripMetadata:  o.StripMetadata && o.Keep == 0,
 		Lossless:       o.Lossless,
 		Palette:        o.Palette,
 		Speed:          o.Speed,
+
+		KeepInterpretation: o.KeepInterpretation,
+		KeepCMYK:           o.KeepCMYK,
+		DisplayP3:          o.DisplayP3,
+		BitDepth:           o.BitDepth,
+		JPEG:               o.JPEG,
+	}
+
+	// Load the ICC profiles given as bytes, if necessary
+	release, err := setICCProfiles(&saveOptions, o)
+	if err != nil {
+		return nil, err
 	}
+	defer release()
+
+	// Search for the lowest quality meeting the target similarity, if necessary
+	if o.TargetQuality > 0 && qualityIsAdjustable(saveOptions) {
+		return saveImageTargetQuality(image, o, saveOptions)
+	}
+
+	// Search for the quality which fits into the max bytes, if necessary
+	if o.MaxBytes > 0 {
+		return saveImageMaxBytes(image, o, saveOptions)
+	}
+
+	result := SaveResult{Quality: o.Quality, Width: int(image.Xsize), Height: int(image.Ysize)}
+
 	// Finally get the resultant buffer
-	return vipsSave(image, saveOptions)
+	buf, err := vipsSave(image, saveOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	o.setResult(result)
+	return buf, nil
 }
 
 func normalizeOperation(o *Options, inWidth, inHeight int) {
@@ -210,7 +294,8 @@ func shouldTransformImage(o Options, inWidth, inHeight int) bool {
 }
 
 func shouldApplyEffects(o Options) bool {
-	return o.GaussianBlur.Sigma > 0 || o.GaussianBlur.MinAmpl > 0 || o.Sharpen.Radius > 0 && o.Sharpen.Y2 > 0 || o.Sharpen.Y3 > 0
+	return o.GaussianBlur.Sigma > 0 || o.GaussianBlur.MinAmpl > 0 || o.Sharpen.Radius > 0 && o.Sharpen.Y2 > 0 || o.Sharpen.Y3 > 0 ||
+		o.Normalize || o.Equalize || o.CLAHE.Width > 0 && o.CLAHE.Height > 0 || len(o.Convolve.Kernel) > 0 || o.Filter != FilterNone
 }
 
 func transformImage(image *C.VipsImage, o Options, shrink int, residual float64) (*C.VipsImage, error) {
@@ -256,6 +341,27 @@ func transformImage(image *C.VipsImage, o Options, shrink int, residual float64)
 func applyEffects(image *C.VipsImage, o Options) (*C.VipsImage, error) {
 	var err error
 
+	if o.Normalize {
+		image, err = vipsNormalize(image, o.NormalizeClip)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if o.Equalize {
+		image, err = vipsEqualize(image)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if o.CLAHE.Width > 0 && o.CLAHE.Height > 0 {
+		image, err = vipsCLAHE(image, o.CLAHE)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	if o.GaussianBlur.Sigma > 0 || o.GaussianBlur.MinAmpl > 0 {
 		image, err = vipsGaussianBlur(image, o.GaussianBlur)
 		if err != nil {
@@ -270,6 +376,23 @@ func applyEffects(image *C.VipsImage, o Options) (*C.VipsImage, error) {
 		}
 	}
 
+	if len(o.Convolve.Kernel) > 0 {
+		if o.Convolve.Width < 1 || o.Convolve.Height < 1 || len(o.Convolve.Kernel) != o.Convolve.Width*o.Convolve.Height {
+			return nil, ErrConvolutionKernel
+		}
+		image, err = vipsConvolve(image, o.Convolve)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if o.Filter != FilterNone {
+		image, err = vipsFilter(image, o.Filter, o.FilterSize)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return image, nil
 }
 
@@ -407,8 +530,56 @@ func watermarkImageWithAnotherImage(image *C.VipsImage, w WatermarkImage) (*C.Vi
 	return image, nil
 }
 
+func removeBackground(image *C.VipsImage, o Options) (*C.VipsImage, error) {
+	if !o.RemoveBackground {
+		return image, nil
+	}
+
+	threshold := o.Threshold
+	if threshold == 0 {
+		threshold = 10
+	}
+	feather := o.Feather
+	if feather == 0 {
+		feather = 1
+	}
+
+	return vipsRemoveBackground(image, o.Background, threshold, feather)
+}
+
+func layoutImage(image *C.VipsImage, o Options) (*C.VipsImage, error) {
+	var err error
+
+	if o.Border.Width > 0 {
+		image, err = vipsBorder(image, o.Border)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if o.Shadow != (Shadow{}) {
+		if o.Shadow.Opacity == 0 {
+			o.Shadow.Opacity = 0.5
+		}
+		image, err = vipsShadow(image, o.Shadow)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if o.Padding != (Padding{}) {
+		image, err = vipsPadding(image, o.Padding, o.Background)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return image, nil
+}
+
 func imageFlatten(image *C.VipsImage, imageType ImageType, o Options) (*C.VipsImage, error) {
-	if o.Background == ColorBlack {
+	// The background colour is the removed one
+	if o.Background == ColorBlack || o.RemoveBackground {
 		return image, nil
 	}
 	return vipsFlattenBackground(image, o.Background)
@@ -425,6 +596,83 @@ func applyGamma(image *C.VipsImage, o Options) (*C.VipsImage, error) {
 	return image, nil
 }
 
+// redactImage hides the regions of the image, mapping their coordinates
+// from the inWidth x inHeight source image to the current image size.
+func redactImage(image *C.VipsImage, regions []Region, inWidth, inHeight int) (*C.VipsImage, error) {
+	var err error
+	xscale := float64(image.Xsize) / float64(inWidth)
+	yscale := float64(image.Ysize) / float64(inHeight)
+
+	for _, r := range regions {
+		left := int(math.Max(0, math.Floor(float64(r.Left)*xscale)))
+		top := int(math.Max(0, math.Floor(float64(r.Top)*yscale)))
+		right := int(math.Min(float64(image.Xsize), math.Ceil(float64(r.Left+r.Width)*xscale)))
+		bottom := int(math.Min(float64(image.Ysize), math.Ceil(float64(r.Top+r.Height)*yscale)))
+		width, height := right-left, bottom-top
+		if width <= 0 || height <= 0 {
+			continue
+		}
+
+		if r.BlockSize == 0 {
+			r.BlockSize = 16
+		}
+		if r.Sigma == 0 {
+			r.Sigma = 20
+		}
+		block := int(math.Max(1, float64(roundFloat(float64(r.BlockSize)*xscale))))
+		block = int(math.Min(float64(block), math.Min(float64(width), float64(height))))
+		sigma := math.Max(0.5, r.Sigma*xscale)
+
+		image, err = vipsRedact(image, left, top, width, height, r.Mode, block, sigma)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return image, nil
+}
+
+func applyModulate(image *C.VipsImage, o Options) (*C.VipsImage, error) {
+	if o.Modulate == (Modulate{}) {
+		return image, nil
+	}
+	return vipsModulate(image, o.Modulate)
+}
+
+func applyColourFilters(image *C.VipsImage, o Options) (*C.VipsImage, error) {
+	var err error
+
+	if o.Greyscale {
+		image, err = vipsGreyscale(image)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if o.Sepia {
+		image, err = vipsSepia(image)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if o.Tint != ColorBlack {
+		image, err = vipsTint(image, o.Tint)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if o.Duotone != [2]Color{} {
+		image, err = vipsDuotone(image, o.Duotone)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return image, nil
+}
+
 func zoomImage(image *C.VipsImage, zoom int) (*C.VipsImage, error) {
 	if zoom == 0 {
 		return image, nil
@@ -452,6 +700,41 @@ func shrinkImage(image *C.VipsImage, o Options, residual float64, shrink int) (*
 	return image, residual, nil
 }
 
+func supportsShrinkOnLoad(imageType ImageType) bool {
+	return imageType == JPEG || (imageType == WEBP && VipsMajorVersion >= 8 && VipsMinorVersion >= 3)
+}
+
+// loadSample loads the image with an integral downscaling which keeps it
+// at least width x height pixels, using shrink-on-load when the format
+// supports it. It is meant for analysis of small versions of the image.
+func loadSample(buf []byte, width, height int) (*C.VipsImage, error) {
+	image, imageType, err := loadImage(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	factor := math.Min(float64(image.Xsize)/float64(width), float64(image.Ysize)/float64(height))
+	shrink := int(math.Floor(factor))
+
+	if supportsShrinkOnLoad(imageType) && shrink >= 2 {
+		image, _, err = shrinkOnLoad(buf, image, imageType, factor, shrink)
+		if err != nil {
+			return nil, err
+		}
+		factor = math.Min(float64(image.Xsize)/float64(width), float64(image.Ysize)/float64(height))
+		shrink = int(math.Floor(factor))
+	}
+
+	if shrink >= 2 {
+		image, err = vipsShrink(image, shrink)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return image, nil
+}
+
 func shrinkOnLoad(buf []byte, input *C.VipsImage, imageType ImageType, factor float64, shrink int) (*C.VipsImage, float64, error) {
 	var (
 		image *C.VipsImage
@@ -489,6 +772,25 @@ func shrinkOnLoad(buf []byte, input *C.VipsImage, imageType ImageType, factor fl
 	return image, factor, err
 }
 
+// resizeImageExact scales the image to the given width and height,
+// regardless of its aspect ratio.
+func resizeImageExact(image *C.VipsImage, width, height int) (*C.VipsImage, error) {
+	hscale := float64(width) / float64(image.Xsize)
+	vscale := float64(height) / float64(image.Ysize)
+
+	image, err := vipsResize(image, hscale, vscale)
+	if err != nil {
+		return nil, err
+	}
+
+	// Rounding may leave the image one pixel off the required size
+	if int(image.Xsize) != width || int(image.Ysize) != height {
+		return vipsEmbed(image, 0, 0, width, height, ExtendCopy, ColorBlack)
+	}
+
+	return image, nil
+}
+
 func imageCalculations(o *Options, inWidth, inHeight int) float64 {
 	factor := 1.0
 	xfactor := float64(inWidth) / float64(o.Width)
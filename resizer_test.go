@@ -330,6 +330,417 @@ func TestSharpen(t *testing.T) {
 	Write("testdata/test_sharpen_out.jpg", newImg)
 }
 
+func TestConvolve(t *testing.T) {
+	options := Options{Width: 800, Height: 600, Convolve: Convolution{
+		Width:  3,
+		Height: 3,
+		Kernel: []float64{-1, -1, -1, -1, 9, -1, -1, -1, -1},
+	}}
+	buf, _ := Read("testdata/test.jpg")
+
+	newImg, err := Resize(buf, options)
+	if err != nil {
+		t.Errorf("Resize(imgData, %#v) error: %#v", options, err)
+	}
+
+	size, _ := Size(newImg)
+	if size.Height != options.Height || size.Width != options.Width {
+		t.Fatalf("Invalid image size: %dx%d", size.Width, size.Height)
+	}
+
+	Write("testdata/test_convolve_out.jpg", newImg)
+}
+
+func TestConvolveInvalidKernel(t *testing.T) {
+	options := Options{Convolve: Convolution{Width: 3, Height: 3, Kernel: []float64{1, 2, 1}}}
+	buf, _ := Read("testdata/test.jpg")
+
+	_, err := Resize(buf, options)
+	if err != ErrConvolutionKernel {
+		t.Fatalf("Expected ErrConvolutionKernel, got: %v", err)
+	}
+}
+
+func TestFilters(t *testing.T) {
+	filters := []struct {
+		name   string
+		filter Filter
+		bands  int
+	}{
+		{"none", FilterNone, 3},
+		{"emboss", FilterEmboss, 3},
+		{"sobel", FilterSobel, 1},
+		{"box_blur", FilterBoxBlur, 3},
+		{"median", FilterMedian, 3},
+		{"detail", FilterDetail, 3},
+		{"canny", FilterCanny, 1},
+	}
+	buf, _ := Read("testdata/test.jpg")
+
+	for _, f := range filters {
+		if f.filter == FilterCanny && VipsMajorVersion == 8 && VipsMinorVersion < 8 {
+			continue
+		}
+
+		options := Options{Width: 400, Filter: f.filter, FilterSize: 5}
+		newImg, err := Resize(buf, options)
+		if err != nil {
+			t.Fatalf("Resize(imgData, %#v) error: %#v", options, err)
+		}
+
+		metadata, err := Metadata(newImg)
+		if err != nil {
+			t.Fatalf("Cannot read the metadata: %s", err)
+		}
+		if metadata.Channels != f.bands {
+			t.Fatalf("Unexpected number of channels for %s: %d", f.name, metadata.Channels)
+		}
+
+		Write(fmt.Sprintf("testdata/test_filter_%s_out.jpg", f.name), newImg)
+	}
+}
+
+func TestFilterValues(t *testing.T) {
+	// The values must match the order of the filters enum in vips.h
+	filters := []Filter{FilterNone, FilterEmboss, FilterSobel, FilterCanny, FilterBoxBlur, FilterMedian, FilterDetail}
+	for i, filter := range filters {
+		if int(filter) != i {
+			t.Fatalf("Unexpected filter value: %d != %d", filter, i)
+		}
+	}
+}
+
+func TestRedactModeValues(t *testing.T) {
+	// The values must match the order of the redact_modes enum in vips.h
+	for i, mode := range []RedactMode{RedactPixelate, RedactBlur} {
+		if int(mode) != i {
+			t.Fatalf("Unexpected redact mode value: %d != %d", mode, i)
+		}
+	}
+}
+
+func TestRedact(t *testing.T) {
+	buf, _ := Read("testdata/test.jpg")
+
+	for _, mode := range []RedactMode{RedactPixelate, RedactBlur} {
+		// The region is defined in source coordinates and scaled down with the image
+		redact := []Region{{Left: 200, Top: 200, Width: 400, Height: 300, Mode: mode}}
+		redacted, err := Resize(buf, Options{Width: 840, Type: PNG, Redact: redact})
+		if err != nil {
+			t.Fatalf("Cannot redact the image: %s", err)
+		}
+		plain, err := Resize(buf, Options{Width: 840, Type: PNG})
+		if err != nil {
+			t.Fatalf("Cannot process the image: %s", err)
+		}
+
+		inside := Options{Top: 110, Left: 110, AreaWidth: 180, AreaHeight: 130, Type: PNG}
+		result, err := compareAreas(redacted, plain, inside)
+		if err != nil {
+			t.Fatalf("Cannot compare the images: %s", err)
+		}
+		if result.SSIM > 0.9 {
+			t.Fatalf("Expected the region to be redacted, SSIM: %f", result.SSIM)
+		}
+
+		outside := Options{Top: 300, Left: 400, AreaWidth: 300, AreaHeight: 200, Type: PNG}
+		result, err = compareAreas(redacted, plain, outside)
+		if err != nil {
+			t.Fatalf("Cannot compare the images: %s", err)
+		}
+		if result.MaxDiff != 0 {
+			t.Fatalf("Expected the rest of the image to be untouched, max difference: %f", result.MaxDiff)
+		}
+
+		Write(fmt.Sprintf("testdata/test_redact_%d_out.png", mode), redacted)
+	}
+}
+
+func compareAreas(a, b []byte, o Options) (CompareResult, error) {
+	areaA, err := Resize(a, o)
+	if err != nil {
+		return CompareResult{}, err
+	}
+	areaB, err := Resize(b, o)
+	if err != nil {
+		return CompareResult{}, err
+	}
+	return Compare(areaA, areaB)
+}
+
+func TestBorderAndPadding(t *testing.T) {
+	options := Options{
+		Width:      300,
+		Height:     200,
+		Crop:       true,
+		Border:     Border{Width: 5, Color: Color{255, 0, 0}},
+		Padding:    Padding{Top: 10, Right: 20, Bottom: 30, Left: 40},
+		Background: Color{255, 255, 255},
+	}
+	buf, _ := Read("testdata/test.jpg")
+
+	newImg, err := Resize(buf, options)
+	if err != nil {
+		t.Fatalf("Resize(imgData, %#v) error: %#v", options, err)
+	}
+
+	err = assertSize(newImg, 300+2*5+20+40, 200+2*5+10+30)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	Write("testdata/test_border_padding_out.jpg", newImg)
+}
+
+func TestBorder16Bit(t *testing.T) {
+	white := Color{255, 255, 255}
+	tests := []struct {
+		name    string
+		options Options
+	}{
+		{"border", Options{Border: Border{Width: 10, Color: white}}},
+		{"border_16bit", Options{Border: Border{Width: 10, Color: white}, KeepInterpretation: true}},
+		{"padding", Options{Padding: Padding{Top: 10, Left: 10}, Background: white}},
+		{"padding_16bit", Options{Padding: Padding{Top: 10, Left: 10}, Background: white, KeepInterpretation: true}},
+	}
+
+	for _, test := range tests {
+		newImg, err := Resize(readImage("test_16bit.png"), test.options)
+		if err != nil {
+			t.Fatalf("Resize(imgData, %#v) error: %#v", test.options, err)
+		}
+		// The PNG bit depth is stored in the IHDR chunk
+		if test.options.KeepInterpretation && newImg[24] != 16 {
+			t.Fatalf("Expected the 16-bit depth to be kept for %s: %d", test.name, newImg[24])
+		}
+
+		corner, err := Resize(newImg, Options{Top: 0, Left: 0, AreaWidth: 10, AreaHeight: 10, Type: PNG})
+		if err != nil {
+			t.Fatalf("Cannot extract the %s: %s", test.name, err)
+		}
+		color, err := AverageColor(corner)
+		if err != nil {
+			t.Fatalf("Cannot read the %s colour: %s", test.name, err)
+		}
+		if color.R < 250 || color.G < 250 || color.B < 250 {
+			t.Fatalf("Unexpected %s colour: %#v", test.name, color)
+		}
+
+		Write(fmt.Sprintf("testdata/test_layout_%s_out.png", test.name), newImg)
+	}
+}
+
+func TestShadow(t *testing.T) {
+	files := []string{"test.jpg", "transparent.png"}
+	shadow := Shadow{Sigma: 5, OffsetX: 10, OffsetY: 8, Opacity: 0.6}
+
+	for _, file := range files {
+		options := Options{Width: 300, Shadow: shadow}
+		buf, _ := Read("testdata/" + file)
+
+		newImg, err := Resize(buf, options)
+		if err != nil {
+			t.Fatalf("Resize(imgData, %#v) error: %#v", options, err)
+		}
+		if DetermineImageType(newImg) != PNG {
+			t.Fatalf("The %s shadow output must be converted to png", file)
+		}
+
+		size, _ := Size(newImg)
+		if margin := 15 + 10; size.Width != 300+2*margin {
+			t.Fatalf("Invalid image width: %d", size.Width)
+		}
+
+		stats, _ := Stats(newImg)
+		if !stats.UsesAlpha {
+			t.Fatalf("Expected transparent pixels around the %s shadow", file)
+		}
+
+		Write(fmt.Sprintf("testdata/test_shadow_%s_out.png", path.Base(file)), newImg)
+	}
+}
+
+func TestRemoveBackground(t *testing.T) {
+	buf, _ := Read("testdata/test.jpg")
+	white := Color{255, 255, 255}
+
+	// Product-like image on a white background
+	product, err := Resize(buf, Options{Width: 200, Height: 200, Crop: true, Padding: Padding{50, 50, 50, 50}, Background: white, Type: PNG})
+	if err != nil {
+		t.Fatalf("Cannot process the image: %s", err)
+	}
+
+	options := Options{RemoveBackground: true, Background: white, Feather: -1}
+	newImg, err := Resize(product, options)
+	if err != nil {
+		t.Fatalf("Resize(imgData, %#v) error: %#v", options, err)
+	}
+	if DetermineImageType(newImg) != PNG {
+		t.Fatal("Image is not png")
+	}
+
+	stats, err := Stats(newImg)
+	if err != nil {
+		t.Fatalf("Cannot compute the image stats: %s", err)
+	}
+	if len(stats.Bands) != 4 {
+		t.Fatalf("Expected an alpha channel, got %d bands", len(stats.Bands))
+	}
+
+	alpha := stats.Bands[3].Histogram
+	if transparent := alpha[0]; transparent < 300*300-200*200 {
+		t.Fatalf("Unexpected number of transparent pixels: %d", transparent)
+	}
+	if opaque := alpha[255]; opaque < 200*200*9/10 {
+		t.Fatalf("Unexpected number of opaque pixels: %d", opaque)
+	}
+
+	Write("testdata/test_remove_background_out.png", newImg)
+}
+
+func TestNormalize(t *testing.T) {
+	buf, _ := Read("testdata/test.jpg")
+
+	// Reduce the contrast first, so there is something to stretch
+	dim, err := Resize(buf, Options{Width: 400, Gamma: 2.2})
+	if err != nil {
+		t.Fatalf("Cannot process the image: %s", err)
+	}
+
+	options := Options{Normalize: true, NormalizeClip: 1}
+	newImg, err := Resize(dim, options)
+	if err != nil {
+		t.Fatalf("Resize(imgData, %#v) error: %#v", options, err)
+	}
+
+	before, _ := Stats(dim)
+	after, _ := Stats(newImg)
+	if after.Bands[0].StdDev <= before.Bands[0].StdDev {
+		t.Fatalf("Expected a higher contrast: %f <= %f", after.Bands[0].StdDev, before.Bands[0].StdDev)
+	}
+
+	Write("testdata/test_normalize_out.jpg", newImg)
+}
+
+func TestEqualize(t *testing.T) {
+	options := Options{Width: 800, Height: 600, Equalize: true}
+	buf, _ := Read("testdata/test.jpg")
+
+	newImg, err := Resize(buf, options)
+	if err != nil {
+		t.Errorf("Resize(imgData, %#v) error: %#v", options, err)
+	}
+
+	size, _ := Size(newImg)
+	if size.Height != options.Height || size.Width != options.Width {
+		t.Fatalf("Invalid image size: %dx%d", size.Width, size.Height)
+	}
+
+	Write("testdata/test_equalize_out.jpg", newImg)
+}
+
+func TestCLAHE(t *testing.T) {
+	options := Options{Width: 800, Height: 600, CLAHE: CLAHE{Width: 64, Height: 64, MaxSlope: 3}}
+	buf, _ := Read("testdata/test.jpg")
+
+	newImg, err := Resize(buf, options)
+	if err != nil {
+		t.Errorf("Resize(imgData, %#v) error: %#v", options, err)
+	}
+
+	size, _ := Size(newImg)
+	if size.Height != options.Height || size.Width != options.Width {
+		t.Fatalf("Invalid image size: %dx%d", size.Width, size.Height)
+	}
+
+	Write("testdata/test_clahe_out.jpg", newImg)
+}
+
+func TestModulate(t *testing.T) {
+	buf, _ := Read("testdata/test.jpg")
+
+	tests := []struct {
+		modulate Modulate
+		check    func(before, after ImageStats) bool
+	}{
+		{Modulate{Brightness: float64Ptr(1.3)}, func(before, after ImageStats) bool {
+			return after.Bands[1].Mean > before.Bands[1].Mean
+		}},
+		{Modulate{Brightness: float64Ptr(0.7)}, func(before, after ImageStats) bool {
+			return after.Bands[1].Mean < before.Bands[1].Mean
+		}},
+		{Modulate{Contrast: float64Ptr(0.5)}, func(before, after ImageStats) bool {
+			return after.Bands[1].StdDev < before.Bands[1].StdDev
+		}},
+		{Modulate{Saturation: float64Ptr(0)}, func(before, after ImageStats) bool {
+			return after.Grayscale && !before.Grayscale
+		}},
+		{Modulate{Brightness: float64Ptr(0)}, func(before, after ImageStats) bool {
+			return after.Bands[1].Mean < 5
+		}},
+		{Modulate{Saturation: float64Ptr(1), Hue: 0}, func(before, after ImageStats) bool {
+			return !after.Grayscale
+		}},
+	}
+
+	before, err := Stats(buf)
+	if err != nil {
+		t.Fatalf("Cannot compute the image stats: %s", err)
+	}
+
+	for _, test := range tests {
+		options := Options{Modulate: test.modulate, Type: PNG}
+		newImg, err := Resize(buf, options)
+		if err != nil {
+			t.Fatalf("Resize(imgData, %#v) error: %#v", options, err)
+		}
+
+		after, err := Stats(newImg)
+		if err != nil {
+			t.Fatalf("Cannot compute the image stats: %s", err)
+		}
+		if !test.check(before, after) {
+			t.Fatalf("Unexpected result for %#v", test.modulate)
+		}
+	}
+}
+
+func float64Ptr(v float64) *float64 {
+	return &v
+}
+
+func TestModulateHue(t *testing.T) {
+	options := Options{Width: 400, Modulate: Modulate{Hue: 180}}
+	buf, _ := Read("testdata/test.jpg")
+
+	newImg, err := Resize(buf, options)
+	if err != nil {
+		t.Fatalf("Resize(imgData, %#v) error: %#v", options, err)
+	}
+
+	Write("testdata/test_modulate_hue_out.jpg", newImg)
+}
+
+func TestNormalizeTransparent(t *testing.T) {
+	options := Options{Normalize: true, Equalize: true, Type: PNG}
+	buf, _ := Read("testdata/transparent.png")
+
+	newImg, err := Resize(buf, options)
+	if err != nil {
+		t.Fatalf("Resize(imgData, %#v) error: %#v", options, err)
+	}
+
+	metadata, err := Metadata(newImg)
+	if err != nil {
+		t.Fatalf("Cannot read the metadata: %s", err)
+	}
+	if !metadata.Alpha {
+		t.Fatal("The alpha channel must be preserved")
+	}
+
+	Write("testdata/test_normalize_transparent_out.png", newImg)
+}
+
 func TestExtractWithDefaultAxis(t *testing.T) {
 	options := Options{AreaWidth: 200, AreaHeight: 200}
 	buf, _ := Read("testdata/test.jpg")
@@ -660,6 +1071,265 @@ func TestSkipCropIfTooSmall(t *testing.T) {
 	}
 }
 
+func TestBitDepth(t *testing.T) {
+	// The PNG bit depth is stored in the IHDR chunk
+	pngBitDepth := func(buf []byte) byte {
+		return buf[24]
+	}
+
+	image16, err := Resize(readImage("test.png"), Options{Width: 200, BitDepth: 16})
+	if err != nil {
+		t.Fatalf("Cannot save the 16-bit image: %s", err)
+	}
+	if depth := pngBitDepth(image16); depth != 16 {
+		t.Fatalf("Unexpected bit depth: %d", depth)
+	}
+
+	newImg, err := Resize(image16, Options{Width: 100, KeepInterpretation: true})
+	if err != nil {
+		t.Fatalf("Cannot resize the 16-bit image: %s", err)
+	}
+	if depth := pngBitDepth(newImg); depth != 16 {
+		t.Fatalf("Expected the 16-bit depth to be kept: %d", depth)
+	}
+
+	newImg, err = Resize(image16, Options{Width: 100})
+	if err != nil {
+		t.Fatalf("Cannot resize the 16-bit image: %s", err)
+	}
+	if depth := pngBitDepth(newImg); depth != 8 {
+		t.Fatalf("Unexpected bit depth: %d", depth)
+	}
+
+	newImg, err = Resize(image16, Options{Width: 100, KeepInterpretation: true, Type: JPEG})
+	if err != nil {
+		t.Fatalf("Cannot convert the 16-bit image: %s", err)
+	}
+	if DetermineImageType(newImg) != JPEG {
+		t.Fatal("Image is not jpeg")
+	}
+
+	Write("testdata/test_bit_depth_out.png", image16)
+}
+
+func TestDisplayP3(t *testing.T) {
+	if VipsMajorVersion <= 8 && VipsMinorVersion < 13 {
+		t.Skip("Skip test in libvips < 8.13")
+		return
+	}
+
+	p3, err := BuiltinICCProfile(ICCProfileP3)
+	if err != nil {
+		t.Fatalf("Cannot load the P3 profile: %s", err)
+	}
+
+	for _, file := range []string{"test.jpg", "test_icc_prophoto.jpg"} {
+		newImg, err := Resize(readImage(file), Options{Width: 300, DisplayP3: true})
+		if err != nil {
+			t.Fatalf("Cannot convert %s to Display P3: %s", file, err)
+		}
+
+		profile, err := ICCProfile(newImg)
+		if err != nil {
+			t.Fatalf("Cannot read the image: %s", err)
+		}
+		if !bytes.Equal(profile, p3) {
+			t.Fatalf("Expected the Display P3 profile in %s", file)
+		}
+	}
+}
+
+func TestCMYK(t *testing.T) {
+	if VipsMajorVersion <= 8 && VipsMinorVersion < 11 {
+		t.Skip("Skip test in libvips < 8.11")
+		return
+	}
+
+	buf := readImage("test.jpg")
+	cmyk, err := Resize(buf, Options{Width: 300, Interpretation: InterpretationCMYK})
+	if err != nil {
+		t.Fatalf("Cannot convert the image to CMYK: %s", err)
+	}
+
+	metadata, err := Metadata(cmyk)
+	if err != nil {
+		t.Fatalf("Cannot read the image: %s", err)
+	}
+	if metadata.Space != "cmyk" || metadata.Channels != 4 {
+		t.Fatalf("Unexpected CMYK image space: %s, %d channels", metadata.Space, metadata.Channels)
+	}
+
+	files := []struct {
+		options Options
+		space   string
+	}{
+		{Options{Width: 200}, "srgb"},
+		{Options{Width: 200, KeepCMYK: true}, "cmyk"},
+		{Options{Width: 200, KeepCMYK: true, Type: TIFF}, "cmyk"},
+		{Options{Width: 200, KeepCMYK: true, Type: PNG}, "srgb"},
+	}
+	for _, file := range files {
+		newImg, err := Resize(cmyk, file.options)
+		if err != nil {
+			t.Fatalf("Cannot resize the CMYK image: %s", err)
+		}
+		metadata, err := Metadata(newImg)
+		if err != nil {
+			t.Fatalf("Cannot read the image: %s", err)
+		}
+		if metadata.Space != file.space {
+			t.Fatalf("Unexpected image space for %#v: %s != %s", file.options, metadata.Space, file.space)
+		}
+	}
+
+	// The colours must survive the CMYK round trip
+	srgb, err := Resize(cmyk, Options{Width: 200})
+	if err != nil {
+		t.Fatalf("Cannot resize the CMYK image: %s", err)
+	}
+	expected, _ := AverageColor(buf)
+	average, err := AverageColor(srgb)
+	if err != nil {
+		t.Fatalf("Cannot compute the average colour: %s", err)
+	}
+	diff := func(a, b uint8) int {
+		if a > b {
+			return int(a - b)
+		}
+		return int(b - a)
+	}
+	if diff(average.R, expected.R) > 16 || diff(average.G, expected.G) > 16 || diff(average.B, expected.B) > 16 {
+		t.Fatalf("Unexpected CMYK round trip colour: %#v != %#v", average, expected)
+	}
+
+	Write("testdata/test_cmyk_out.jpg", cmyk)
+}
+
+func TestJPEGOptions(t *testing.T) {
+	// jpegLumaSampling returns the sampling factors of the first
+	// component, read from the start of frame segment
+	jpegLumaSampling := func(buf []byte) byte {
+		for i := 2; i+4 < len(buf); {
+			marker := buf[i+1]
+			length := int(buf[i+2])<<8 | int(buf[i+3])
+			if marker == 0xC0 || marker == 0xC1 || marker == 0xC2 {
+				return buf[i+11]
+			}
+			i += 2 + length
+		}
+		return 0
+	}
+
+	buf := readImage("test.jpg")
+	options := []struct {
+		name     string
+		jpeg     JPEGOptions
+		sampling byte
+	}{
+		{"default", JPEGOptions{}, 0x22},
+		{"444", JPEGOptions{ChromaSubsampling: ChromaSubsampling444}, 0x11},
+		{"mozjpeg", JPEGPresetMozJPEG, 0x22},
+	}
+
+	for _, o := range options {
+		newImg, err := Resize(buf, Options{Width: 400, Quality: 80, JPEG: o.jpeg})
+		if err != nil {
+			t.Fatalf("Cannot save the %s JPEG: %s", o.name, err)
+		}
+		if DetermineImageType(newImg) != JPEG {
+			t.Fatalf("The %s image is not jpeg", o.name)
+		}
+		if sampling := jpegLumaSampling(newImg); sampling != o.sampling {
+			t.Fatalf("Unexpected %s chroma subsampling: %#x != %#x", o.name, sampling, o.sampling)
+		}
+
+		Write(fmt.Sprintf("testdata/test_jpeg_%s_out.jpg", o.name), newImg)
+	}
+
+	if VipsMajorVersion > 8 || VipsMinorVersion >= 10 {
+		newImg, err := Resize(buf, Options{Width: 400, Quality: 95, JPEG: JPEGOptions{ChromaSubsampling: ChromaSubsampling420}})
+		if err != nil {
+			t.Fatalf("Cannot save the JPEG: %s", err)
+		}
+		if sampling := jpegLumaSampling(newImg); sampling != 0x22 {
+			t.Fatalf("Expected a subsampled chroma at high quality: %#x", sampling)
+		}
+	}
+}
+
+func TestJPEGPresetProgressive(t *testing.T) {
+	// jpegFrameMarker returns the start of frame marker,
+	// 0xC2 for progressive images
+	jpegFrameMarker := func(buf []byte) byte {
+		for i := 2; i+4 < len(buf); {
+			marker := buf[i+1]
+			length := int(buf[i+2])<<8 | int(buf[i+3])
+			if marker >= 0xC0 && marker <= 0xC2 {
+				return marker
+			}
+			i += 2 + length
+		}
+		return 0
+	}
+
+	newImg, err := Resize(readImage("test.jpg"), Options{Width: 400, JPEG: JPEGPresetMozJPEG})
+	if err != nil {
+		t.Fatalf("Cannot save the JPEG: %s", err)
+	}
+	if marker := jpegFrameMarker(newImg); marker != 0xC2 {
+		t.Fatalf("Expected a progressive JPEG: %#x", marker)
+	}
+
+	newImg, err = Resize(readImage("test.jpg"), Options{Width: 400})
+	if err != nil {
+		t.Fatalf("Cannot save the JPEG: %s", err)
+	}
+	if marker := jpegFrameMarker(newImg); marker == 0xC2 {
+		t.Fatal("Unexpected progressive JPEG")
+	}
+}
+
+func TestNewJPEGSaveOptions(t *testing.T) {
+	if opts := newJPEGSaveOptions(JPEGOptions{}); opts.OptimizeCoding != 1 {
+		t.Fatal("Expected the optimal Huffman tables by default")
+	}
+	if opts := newJPEGSaveOptions(JPEGOptions{TrellisQuant: true}); opts.OptimizeCoding != 1 || opts.TrellisQuant != 1 {
+		t.Fatalf("Unexpected JPEG save options: %#v", opts)
+	}
+	if opts := newJPEGSaveOptions(JPEGOptions{DisableOptimizeCoding: true}); opts.OptimizeCoding != 0 {
+		t.Fatalf("Unexpected JPEG save options: %#v", opts)
+	}
+	opts := newJPEGSaveOptions(JPEGOptions{ChromaSubsampling: ChromaSubsampling444, QuantTable: 3})
+	if opts.SubsampleMode != 2 || opts.QuantTable != 3 {
+		t.Fatalf("Unexpected JPEG save options: %#v", opts)
+	}
+}
+
+func TestJPEGOptionsSize(t *testing.T) {
+	buf := readImage("test.jpg")
+
+	// Setting a single option must keep the optimal Huffman tables
+	defaultImg, err := Resize(buf, Options{Width: 400, Quality: 80})
+	if err != nil {
+		t.Fatalf("Cannot save the JPEG: %s", err)
+	}
+	newImg, err := Resize(buf, Options{Width: 400, Quality: 80, JPEG: JPEGOptions{ChromaSubsampling: ChromaSubsampling420}})
+	if err != nil {
+		t.Fatalf("Cannot save the JPEG: %s", err)
+	}
+	if len(newImg) > len(defaultImg) {
+		t.Fatalf("Unexpected JPEG size regression: %d > %d", len(newImg), len(defaultImg))
+	}
+
+	unoptimizedImg, err := Resize(buf, Options{Width: 400, Quality: 80, JPEG: JPEGOptions{DisableOptimizeCoding: true}})
+	if err != nil {
+		t.Fatalf("Cannot save the JPEG: %s", err)
+	}
+	if len(unoptimizedImg) <= len(defaultImg) {
+		t.Fatalf("Expected standard Huffman tables to be larger: %d <= %d", len(unoptimizedImg), len(defaultImg))
+	}
+}
+
 func runBenchmarkResize(file string, o Options, b *testing.B) {
 	buf, _ := Read(path.Join("testdata", file))
 
@@ -0,0 +1,161 @@
+package bimg
+
+/*
+#cgo pkg-config: vips
+#include "vips/vips.h"
+*/
+import "C"
+
+import (
+	"runtime"
+)
+
+const (
+	// grayscaleTolerance is the maximum difference between the colour
+	// bands of a pixel which is still considered grey.
+	grayscaleTolerance = 8
+	// blankTolerance is the distance to the most frequent value of a
+	// band within which a pixel is considered part of the background.
+	blankTolerance = 10
+	// blankRatio is the minimum ratio of background pixels of a mostly blank image.
+	blankRatio = 0.98
+	// statsColumns is the number of columns of the libvips stats matrix.
+	statsColumns = 10
+)
+
+// BandStats represents the statistics of a single image band.
+// Values are in the 0-255 range of 8-bit samples.
+type BandStats struct {
+	Min    float64
+	Max    float64
+	Mean   float64
+	StdDev float64
+	// Histogram holds the number of pixels of every value.
+	Histogram []int
+}
+
+// ImageStats represents the pixel statistics of an image.
+type ImageStats struct {
+	// Bands holds the statistics of every band, including the alpha channel.
+	Bands []BandStats
+	// Grayscale is true when every pixel has the same value
+	// in all the colour bands, with a small tolerance.
+	Grayscale bool
+	// MostlyBlank is true when nearly all the pixels have the same colour,
+	// such as empty scans or placeholder images.
+	MostlyBlank bool
+	// UsesAlpha is true when the image has an alpha channel
+	// with at least one non opaque pixel.
+	UsesAlpha bool
+}
+
+// Stats returns the per band statistics and histograms of the image pixels.
+func Stats(buf []byte) (ImageStats, error) {
+	defer C.vips_thread_shutdown()
+	defer runtime.KeepAlive(buf)
+
+	image, _, err := vipsRead(buf)
+	if err != nil {
+		return ImageStats{}, err
+	}
+
+	image, err = vipsUchar(image)
+	if err != nil {
+		return ImageStats{}, err
+	}
+	defer C.g_object_unref(C.gpointer(image))
+
+	stats, err := vipsStats(image)
+	if err != nil {
+		return ImageStats{}, err
+	}
+
+	hist, err := vipsHistFind(image)
+	if err != nil {
+		return ImageStats{}, err
+	}
+
+	bands := int(image.Bands)
+	result := ImageStats{Bands: make([]BandStats, bands)}
+	for b := range result.Bands {
+		// The first row holds the statistics of all the bands
+		row := stats[(b+1)*statsColumns:]
+		band := BandStats{
+			Min:       row[0],
+			Max:       row[1],
+			Mean:      row[4],
+			StdDev:    row[5],
+			Histogram: make([]int, 256),
+		}
+		for i := range band.Histogram {
+			band.Histogram[i] = int(hist[i*bands+b])
+		}
+		result.Bands[b] = band
+	}
+
+	colourBands := bands
+	if vipsHasAlpha(image) {
+		colourBands--
+		result.UsesAlpha = result.Bands[bands-1].Min < 255
+	}
+
+	result.Grayscale, err = isGrayscale(image, colourBands)
+	if err != nil {
+		return ImageStats{}, err
+	}
+	result.MostlyBlank = isMostlyBlank(result.Bands[:colourBands])
+
+	return result, nil
+}
+
+func isGrayscale(image *C.VipsImage, colourBands int) (bool, error) {
+	if colourBands < 3 {
+		return true, nil
+	}
+	if vipsInterpretation(image) == InterpretationCMYK {
+		return false, nil
+	}
+
+	diff, err := vipsBandDifference(image)
+	if err != nil {
+		return false, err
+	}
+	defer C.g_object_unref(C.gpointer(diff))
+
+	stats, err := vipsStats(diff)
+	if err != nil {
+		return false, err
+	}
+
+	return stats[1] <= grayscaleTolerance, nil
+}
+
+// isMostlyBlank checks whether nearly all the pixels of
+// every band are close to the most frequent value.
+func isMostlyBlank(bands []BandStats) bool {
+	if len(bands) == 0 {
+		return false
+	}
+
+	for _, band := range bands {
+		peak, total := 0, 0
+		for i, count := range band.Histogram {
+			total += count
+			if count > band.Histogram[peak] {
+				peak = i
+			}
+		}
+
+		background := 0
+		for i := peak - blankTolerance; i <= peak+blankTolerance; i++ {
+			if i >= 0 && i < len(band.Histogram) {
+				background += band.Histogram[i]
+			}
+		}
+
+		if total == 0 || float64(background)/float64(total) < blankRatio {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,93 @@
+package bimg
+
+import (
+	"testing"
+)
+
+func TestStats(t *testing.T) {
+	buf := readImage("test.jpg")
+
+	stats, err := Stats(buf)
+	if err != nil {
+		t.Fatalf("Cannot compute the image stats: %s", err)
+	}
+
+	if len(stats.Bands) != 3 {
+		t.Fatalf("Unexpected number of bands: %d", len(stats.Bands))
+	}
+
+	size, _ := Size(buf)
+	for b, band := range stats.Bands {
+		if band.Min > band.Mean || band.Mean > band.Max {
+			t.Fatalf("Unexpected band %d stats: %+v", b, band)
+		}
+
+		total := 0
+		for _, count := range band.Histogram {
+			total += count
+		}
+		if total != size.Width*size.Height {
+			t.Fatalf("Unexpected band %d histogram total: %d", b, total)
+		}
+	}
+
+	if stats.Grayscale || stats.MostlyBlank || stats.UsesAlpha {
+		t.Fatalf("Unexpected flags: %+v", stats)
+	}
+}
+
+func TestStatsGrayscale(t *testing.T) {
+	buf, err := Resize(readImage("test.jpg"), Options{Interpretation: InterpretationBW})
+	if err != nil {
+		t.Fatalf("Cannot process the image: %s", err)
+	}
+
+	stats, err := Stats(buf)
+	if err != nil {
+		t.Fatalf("Cannot compute the image stats: %s", err)
+	}
+	if !stats.Grayscale {
+		t.Fatal("Expected a grayscale image")
+	}
+}
+
+func TestStatsAlpha(t *testing.T) {
+	stats, err := Stats(readImage("transparent.png"))
+	if err != nil {
+		t.Fatalf("Cannot compute the image stats: %s", err)
+	}
+	if !stats.UsesAlpha {
+		t.Fatal("Expected the alpha channel to be used")
+	}
+}
+
+func TestStatsMostlyBlank(t *testing.T) {
+	buf, err := BlurHashImage("00Q0:L", 64, 64, PNG)
+	if err != nil {
+		t.Fatalf("Cannot create the image: %s", err)
+	}
+
+	stats, err := Stats(buf)
+	if err != nil {
+		t.Fatalf("Cannot compute the image stats: %s", err)
+	}
+	if !stats.MostlyBlank {
+		t.Fatal("Expected a mostly blank image")
+	}
+}
+
+func TestIsMostlyBlank(t *testing.T) {
+	band := BandStats{Histogram: make([]int, 256)}
+	band.Histogram[250] = 95
+	band.Histogram[255] = 4
+	band.Histogram[0] = 1
+
+	if !isMostlyBlank([]BandStats{band}) {
+		t.Fatal("Expected a mostly blank band")
+	}
+
+	band.Histogram[0] = 10
+	if isMostlyBlank([]BandStats{band}) {
+		t.Fatal("Expected a non blank band")
+	}
+}
@@ -57,6 +57,13 @@ type vipsSaveOptions struct {
 	OutputICC      string // Absolute path to the output ICC profile
 	Interpretation Interpretation
 	Palette        bool
+
+	// The following fields mirror the Options fields
+	KeepInterpretation bool
+	KeepCMYK           bool
+	DisplayP3          bool
+	BitDepth           int
+	JPEG               JPEGOptions
 }
 
 type vipsWatermarkOptions struct {
@@ -79,6 +86,15 @@ type vipsWatermarkTextOptions struct {
 	Font *C.char
 }
 
+type vipsJPEGSaveOptions struct {
+	SubsampleMode      C.int
+	OptimizeCoding     C.int
+	TrellisQuant       C.int
+	OvershootDeringing C.int
+	OptimizeScans      C.int
+	QuantTable         C.int
+}
+
 func init() {
 	Initialize()
 }
@@ -303,7 +319,7 @@ func vipsTransformICC(image *C.VipsImage, inputICC string, outputICC string) (*C
 	defer C.free(unsafe.Pointer(outputIccPath))
 	inputIccPath := C.CString(inputICC)
 	defer C.free(unsafe.Pointer(inputIccPath))
-	err := C.vips_icc_transform_with_default_bridge(image, &out, outputIccPath, inputIccPath)
+	err := C.vips_icc_transform_with_default_bridge(image, &out, outputIccPath, inputIccPath, vipsBitDepth(vipsInterpretation(image)))
 	//err := C.vips_icc_transform_bridge2(image, &outImage, outputIccPath, inputIccPath)
 	if int(err) != 0 {
 		return nil, catchVipsError()
@@ -312,6 +328,56 @@ func vipsTransformICC(image *C.VipsImage, inputICC string, outputICC string) (*C
 	return out, nil
 }
 
+// vipsTransformEmbeddedICC transforms the image from its embedded
+// ICC profile to the given output profile path or built-in name.
+func vipsTransformEmbeddedICC(image *C.VipsImage, outputICC string) (*C.VipsImage, error) {
+	var out *C.VipsImage
+	defer C.g_object_unref(C.gpointer(image))
+
+	outputIccPath := C.CString(outputICC)
+	defer C.free(unsafe.Pointer(outputIccPath))
+	err := C.vips_icc_transform_bridge(image, &out, outputIccPath, vipsBitDepth(vipsInterpretation(image)))
+	if int(err) != 0 {
+		return nil, catchVipsError()
+	}
+
+	return out, nil
+}
+
+// vipsHasBuiltinICC reports whether libvips can transform images with its
+// built-in profiles such as ICCProfileSRGB, which requires libvips 8.8+
+// built with lcms.
+func vipsHasBuiltinICC() bool {
+	if VipsMajorVersion < 8 || (VipsMajorVersion == 8 && VipsMinorVersion < 8) {
+		return false
+	}
+	return int(C.vips_icc_present()) == 1
+}
+
+// vipsBitDepth returns the bits per sample of the interpretation.
+func vipsBitDepth(interpretation Interpretation) C.int {
+	if is16Bit(interpretation) {
+		return 16
+	}
+	return 8
+}
+
+func vipsProfileLoad(name string) ([]byte, error) {
+	var data unsafe.Pointer
+	var length C.size_t
+
+	cname := C.CString(name)
+	defer C.free(unsafe.Pointer(cname))
+
+	err := C.vips_profile_load_bridge(cname, &data, &length)
+	if int(err) != 0 {
+		return nil, catchVipsError()
+	}
+	defer C.g_free(C.gpointer(data))
+
+	return C.GoBytes(data, C.int(length)), nil
+}
+
 func vipsFlip(image *C.VipsImage, direction Direction) (*C.VipsImage, error) {
 	var out *C.VipsImage
 	defer C.g_object_unref(C.gpointer(image))
@@ -364,6 +430,16 @@ func vipsWatermark(image *C.VipsImage, w Watermark) (*C.VipsImage, error) {
 }
 
 func vipsRead(buf []byte) (*C.VipsImage, ImageType, error) {
+	return vipsReadAccess(buf, C.VIPS_ACCESS_RANDOM)
+}
+
+// vipsReadHeader opens the image for sequential access, which is enough
+// to read its header without decoding nor caching the pixels.
+func vipsReadHeader(buf []byte) (*C.VipsImage, ImageType, error) {
+	return vipsReadAccess(buf, C.VIPS_ACCESS_SEQUENTIAL)
+}
+
+func vipsReadAccess(buf []byte, access C.VipsAccess) (*C.VipsImage, ImageType, error) {
 	var image *C.VipsImage
 	imageType := vipsImageType(buf)
 
@@ -374,7 +450,7 @@ func vipsRead(buf []byte) (*C.VipsImage, ImageType, error) {
 	length := C.size_t(len(buf))
 	imageBuf := unsafe.Pointer(&buf[0])
 
-	err := C.vips_init_image(imageBuf, length, C.int(imageType), &image)
+	err := C.vips_init_image_access(imageBuf, length, C.int(imageType), access, &image)
 	if err != 0 {
 		return nil, UNKNOWN, catchVipsError()
 	}
@@ -382,6 +458,10 @@ func vipsRead(buf []byte) (*C.VipsImage, ImageType, error) {
 	return image, imageType, nil
 }
 
+func vipsPages(image *C.VipsImage) int {
+	return int(C.vips_n_pages_bridge(image))
+}
+
 func vipsColourspaceIsSupportedBuffer(buf []byte) (bool, error) {
 	image, _, err := vipsRead(buf)
 	if err != nil {
@@ -433,16 +513,42 @@ func vipsFlattenBackground(image *C.VipsImage, background Color) (*C.VipsImage,
 
 func vipsPreSave(image *C.VipsImage, o *vipsSaveOptions) (*C.VipsImage, error) {
 	var outImage *C.VipsImage
-	// Remove ICC profile metadata
-	if o.NoProfile {
-		C.remove_profile(image)
-	}
+	in := image
+	source := vipsInterpretation(image)
 
 	// Use a default interpretation and cast it to C type
 	if o.Interpretation == 0 {
 		o.Interpretation = InterpretationSRGB
 	}
+	o.Interpretation = saveInterpretation(source, *o)
 	interpretation := C.VipsInterpretation(o.Interpretation)
+	depth := vipsBitDepth(o.Interpretation)
+
+	// Import CMYK images with their embedded profile, falling back
+	// to InputICC or the built-in CMYK profile. Without built-in
+	// profiles, the colour space conversion below is used instead.
+	if source == InterpretationCMYK && o.Interpretation != InterpretationCMYK && vipsHasBuiltinICC() {
+		inputICC := ICCProfileCMYK
+		if o.InputICC != "" {
+			inputICC = o.InputICC
+			o.InputICC = ""
+		}
+		outputIccPath := C.CString(ICCProfileSRGB)
+		defer C.free(unsafe.Pointer(outputIccPath))
+		inputIccPath := C.CString(inputICC)
+		defer C.free(unsafe.Pointer(inputIccPath))
+
+		err := C.vips_icc_transform_fallback_bridge(image, &outImage, outputIccPath, inputIccPath, depth)
+		if int(err) != 0 {
+			return nil, catchVipsError()
+		}
+		image = outImage
+	}
+
+	// Remove ICC profile metadata
+	if o.NoProfile {
+		C.remove_profile(image)
+	}
 
 	// Apply the proper colour space
 	if vipsColourspaceIsSupported(image) {
@@ -450,6 +556,9 @@ func vipsPreSave(image *C.VipsImage, o *vipsSaveOptions) (*C.VipsImage, error) {
 		if int(err) != 0 {
 			return nil, catchVipsError()
 		}
+		if image != in {
+			C.g_object_unref(C.gpointer(image))
+		}
 		image = outImage
 	}
 
@@ -460,7 +569,7 @@ func vipsPreSave(image *C.VipsImage, o *vipsSaveOptions) (*C.VipsImage, error) {
 		inputIccPath := C.CString(o.InputICC)
 		defer C.free(unsafe.Pointer(inputIccPath))
 
-		err := C.vips_icc_transform_with_default_bridge(image, &outImage, outputIccPath, inputIccPath)
+		err := C.vips_icc_transform_with_default_bridge(image, &outImage, outputIccPath, inputIccPath, depth)
 		if int(err) != 0 {
 			return nil, catchVipsError()
 		}
@@ -472,7 +581,22 @@ func vipsPreSave(image *C.VipsImage, o *vipsSaveOptions) (*C.VipsImage, error) {
 		outputIccPath := C.CString(o.OutputICC)
 		defer C.free(unsafe.Pointer(outputIccPath))
 
-		err := C.vips_icc_transform_bridge(image, &outImage, outputIccPath)
+		err := C.vips_icc_transform_bridge(image, &outImage, outputIccPath, depth)
+		if int(err) != 0 {
+			return nil, catchVipsError()
+		}
+		C.g_object_unref(C.gpointer(image))
+		image = outImage
+	}
+
+	// Convert to Display P3, assuming sRGB when no profile is embedded
+	if o.DisplayP3 && o.OutputICC == "" {
+		outputIccPath := C.CString(ICCProfileP3)
+		defer C.free(unsafe.Pointer(outputIccPath))
+		inputIccPath := C.CString(ICCProfileSRGB)
+		defer C.free(unsafe.Pointer(inputIccPath))
+
+		err := C.vips_icc_transform_fallback_bridge(image, &outImage, outputIccPath, inputIccPath, depth)
 		if int(err) != 0 {
 			return nil, catchVipsError()
 		}
@@ -483,6 +607,56 @@ func vipsPreSave(image *C.VipsImage, o *vipsSaveOptions) (*C.VipsImage, error) {
 	return image, nil
 }
 
+// saveInterpretation returns the interpretation of the saved image,
+// given the interpretation of the source image.
+func saveInterpretation(source Interpretation, o vipsSaveOptions) Interpretation {
+	if o.KeepCMYK && source == InterpretationCMYK && supportsCMYK(o.Type) {
+		return InterpretationCMYK
+	}
+
+	interpretation := o.Interpretation
+	if o.KeepInterpretation {
+		switch source {
+		case InterpretationSRGB, InterpretationBW, InterpretationRGB16, InterpretationGREY16:
+			interpretation = source
+		}
+	}
+
+	depth := o.BitDepth
+	if depth == 0 && is16Bit(interpretation) {
+		depth = 16
+	}
+	if depth == 16 && !supports16Bit(o.Type) {
+		depth = 8
+	}
+
+	switch {
+	case depth == 16 && interpretation == InterpretationSRGB:
+		return InterpretationRGB16
+	case depth == 16 && interpretation == InterpretationBW:
+		return InterpretationGREY16
+	case depth == 8 && interpretation == InterpretationRGB16:
+		return InterpretationSRGB
+	case depth == 8 && interpretation == InterpretationGREY16:
+		return InterpretationBW
+	}
+	return interpretation
+}
+
+func is16Bit(interpretation Interpretation) bool {
+	return interpretation == InterpretationRGB16 || interpretation == InterpretationGREY16
+}
+
+// supportsCMYK reports whether the image type can be saved as CMYK.
+func supportsCMYK(t ImageType) bool {
+	return t == JPEG || t == TIFF
+}
+
+// supports16Bit reports whether the image type can be saved with 16 bits per sample.
+func supports16Bit(t ImageType) bool {
+	return t == PNG || t == TIFF || t == AVIF
+}
+
 func vipsSave(image *C.VipsImage, o vipsSaveOptions) ([]byte, error) {
 	defer C.g_object_unref(C.gpointer(image))
 
@@ -523,9 +697,14 @@ func vipsSave(image *C.VipsImage, o vipsSaveOptions) ([]byte, error) {
 	case HEIF:
 		saveErr = C.vips_heifsave_bridge(tmpImage, &ptr, &length, strip, quality, lossless)
 	case AVIF:
-		saveErr = C.vips_avifsave_bridge(tmpImage, &ptr, &length, strip, quality, lossless, speed)
+		saveErr = C.vips_avifsave_bridge(tmpImage, &ptr, &length, strip, quality, lossless, speed, vipsBitDepth(o.Interpretation))
 	default:
-		saveErr = C.vips_jpegsave_bridge(tmpImage, &ptr, &length, strip, quality, interlace)
+		jpegOpts := newJPEGSaveOptions(o.JPEG)
+		// The optimised scans only apply to progressive images
+		if o.JPEG.OptimizeScans {
+			interlace = 1
+		}
+		saveErr = C.vips_jpegsave_bridge(tmpImage, &ptr, &length, strip, quality, interlace, (*C.JpegSaveOptions)(unsafe.Pointer(&jpegOpts)))
 	}
 
 	if int(saveErr) != 0 {
@@ -541,6 +720,19 @@ func vipsSave(image *C.VipsImage, o vipsSaveOptions) ([]byte, error) {
 	return buf, nil
 }
 
+// newJPEGSaveOptions converts the JPEG options, enabling the optimal
+// Huffman tables unless disabled as bimg always did.
+func newJPEGSaveOptions(o JPEGOptions) vipsJPEGSaveOptions {
+	return vipsJPEGSaveOptions{
+		SubsampleMode:      C.int(o.ChromaSubsampling),
+		OptimizeCoding:     C.int(boolToInt(!o.DisableOptimizeCoding)),
+		TrellisQuant:       C.int(boolToInt(o.TrellisQuant)),
+		OvershootDeringing: C.int(boolToInt(o.OvershootDeringing)),
+		OptimizeScans:      C.int(boolToInt(o.OptimizeScans)),
+		QuantTable:         C.int(o.QuantTable),
+	}
+}
+
 func getImageBuffer(image *C.VipsImage) ([]byte, error) {
 	var ptr unsafe.Pointer
 
@@ -548,8 +740,9 @@ func getImageBuffer(image *C.VipsImage) ([]byte, error) {
 	interlace := C.int(0)
 	quality := C.int(100)
 
+	jpegOpts := newJPEGSaveOptions(JPEGOptions{})
 	err := C.int(0)
-	err = C.vips_jpegsave_bridge(image, &ptr, &length, 1, quality, interlace)
+	err = C.vips_jpegsave_bridge(image, &ptr, &length, 1, quality, interlace, (*C.JpegSaveOptions)(unsafe.Pointer(&jpegOpts)))
 	if int(err) != 0 {
 		return nil, catchVipsError()
 	}
@@ -560,6 +753,219 @@ func getImageBuffer(image *C.VipsImage) ([]byte, error) {
 	return C.GoBytes(ptr, C.int(length)), nil
 }
 
+func vipsCopyMemory(image *C.VipsImage) (*C.VipsImage, error) {
+	defer C.g_object_unref(C.gpointer(image))
+
+	out := C.vips_image_copy_memory(image)
+	if out == nil {
+		return nil, catchVipsError()
+	}
+
+	return out, nil
+}
+
+// vipsCopy returns a new image sharing the pixels of the given one,
+// whose metadata can be safely modified.
+func vipsCopy(image *C.VipsImage) (*C.VipsImage, error) {
+	var out *C.VipsImage
+	defer C.g_object_unref(C.gpointer(image))
+
+	err := C.vips_copy_bridge(image, &out)
+	if err != 0 {
+		return nil, catchVipsError()
+	}
+
+	return out, nil
+}
+
+func vipsSetString(image *C.VipsImage, name, value string) {
+	cname := C.CString(name)
+	defer C.free(unsafe.Pointer(cname))
+	cvalue := C.CString(value)
+	defer C.free(unsafe.Pointer(cvalue))
+
+	C.vips_image_set_string(image, cname, cvalue)
+}
+
+func vipsSetInt(image *C.VipsImage, name string, value int) {
+	cname := C.CString(name)
+	defer C.free(unsafe.Pointer(cname))
+
+	C.vips_image_set_int(image, cname, C.int(value))
+}
+
+func vipsSetBlob(image *C.VipsImage, name string, data []byte) {
+	cname := C.CString(name)
+	defer C.free(unsafe.Pointer(cname))
+
+	C.vips_image_set_blob_bridge(image, cname, unsafe.Pointer(&data[0]), C.size_t(len(data)))
+}
+
+func vipsRemoveField(image *C.VipsImage, name string) {
+	cname := C.CString(name)
+	defer C.free(unsafe.Pointer(cname))
+
+	C.vips_image_remove(image, cname)
+}
+
+func vipsImageFields(image *C.VipsImage) []string {
+	fields := C.vips_image_get_fields(image)
+	defer C.g_strfreev(fields)
+
+	var names []string
+	list := (*[1 << 16]*C.gchar)(unsafe.Pointer(fields))
+	for i := 0; list[i] != nil; i++ {
+		names = append(names, C.GoString((*C.char)(list[i])))
+	}
+	return names
+}
+
+// vipsImageFieldString returns the field value formatted as a string,
+// or false if the field is a binary blob or cannot be formatted.
+func vipsImageFieldString(image *C.VipsImage, name string) (string, bool) {
+	cname := C.CString(name)
+	defer C.free(unsafe.Pointer(cname))
+
+	if C.vips_image_get_typeof(image, cname) == C.vips_blob_get_type() {
+		return "", false
+	}
+
+	var out *C.char
+	if C.vips_image_get_as_string(image, cname, &out) != 0 {
+		C.vips_error_clear()
+		return "", false
+	}
+	defer C.g_free(C.gpointer(out))
+
+	return C.GoString(out), true
+}
+
+func vipsImageBlob(image *C.VipsImage, name string) []byte {
+	cname := C.CString(name)
+	defer C.free(unsafe.Pointer(cname))
+
+	if C.vips_image_get_typeof(image, cname) == 0 {
+		return nil
+	}
+
+	var data unsafe.Pointer
+	var length C.size_t
+	if C.vips_image_get_blob(image, cname, &data, &length) != 0 {
+		C.vips_error_clear()
+		return nil
+	}
+
+	return C.GoBytes(data, C.int(length))
+}
+
+func vipsSSIM(a *C.VipsImage, b *C.VipsImage) (float64, error) {
+	ssim := C.double(0)
+
+	err := C.vips_ssim_bridge(a, b, &ssim)
+	if err != 0 {
+		return 0, catchVipsError()
+	}
+
+	return float64(ssim), nil
+}
+
+func vipsColourspace(image *C.VipsImage, interpretation Interpretation) (*C.VipsImage, error) {
+	var out *C.VipsImage
+	defer C.g_object_unref(C.gpointer(image))
+
+	err := C.vips_colourspace_bridge(image, &out, C.VipsInterpretation(interpretation))
+	if err != 0 {
+		return nil, catchVipsError()
+	}
+
+	return out, nil
+}
+
+func vipsCastUchar(image *C.VipsImage) (*C.VipsImage, error) {
+	var out *C.VipsImage
+	defer C.g_object_unref(C.gpointer(image))
+
+	err := C.vips_cast_bridge(image, &out, C.VIPS_FORMAT_UCHAR)
+	if err != 0 {
+		return nil, catchVipsError()
+	}
+
+	return out, nil
+}
+
+// vipsImagePixels renders the image and returns its raw pixel values.
+func vipsImagePixels(image *C.VipsImage) ([]byte, error) {
+	size := C.size_t(0)
+
+	ptr := C.vips_image_write_to_memory(image, &size)
+	if ptr == nil {
+		return nil, catchVipsError()
+	}
+	defer C.g_free(C.gpointer(ptr))
+
+	return C.GoBytes(ptr, C.int(size)), nil
+}
+
+// vipsImageFromPixels creates an image from raw 8-bit pixel values.
+func vipsImageFromPixels(pixels []byte, width, height, bands int) (*C.VipsImage, error) {
+	var image *C.VipsImage
+
+	err := C.vips_image_from_memory_bridge(unsafe.Pointer(&pixels[0]), C.size_t(len(pixels)),
+		C.int(width), C.int(height), C.int(bands), &image)
+	if err != 0 {
+		return nil, catchVipsError()
+	}
+
+	return image, nil
+}
+
+func vipsAddAlpha(image *C.VipsImage) (*C.VipsImage, error) {
+	var out *C.VipsImage
+	defer C.g_object_unref(C.gpointer(image))
+
+	err := C.vips_add_band(image, &out, 255.0)
+	if err != 0 {
+		return nil, catchVipsError()
+	}
+
+	return out, nil
+}
+
+func vipsComparePrepare(image *C.VipsImage) (*C.VipsImage, error) {
+	var out *C.VipsImage
+	defer C.g_object_unref(C.gpointer(image))
+
+	err := C.vips_compare_prepare_bridge(image, &out)
+	if err != 0 {
+		return nil, catchVipsError()
+	}
+
+	return out, nil
+}
+
+func vipsCompare(a *C.VipsImage, b *C.VipsImage) (float64, float64, error) {
+	mse := C.double(0)
+	maxDiff := C.double(0)
+
+	err := C.vips_compare_bridge(a, b, &mse, &maxDiff)
+	if err != 0 {
+		return 0, 0, catchVipsError()
+	}
+
+	return float64(mse), float64(maxDiff), nil
+}
+
+func vipsDiffImage(a *C.VipsImage, b *C.VipsImage) (*C.VipsImage, error) {
+	var out *C.VipsImage
+
+	err := C.vips_diff_image_bridge(a, b, &out)
+	if err != 0 {
+		return nil, catchVipsError()
+	}
+
+	return out, nil
+}
+
 func vipsExtract(image *C.VipsImage, left, top, width, height int) (*C.VipsImage, error) {
 	var buf *C.VipsImage
 	defer C.g_object_unref(C.gpointer(image))
@@ -662,6 +1068,86 @@ func vipsReduce(input *C.VipsImage, xshrink float64, yshrink float64) (*C.VipsIm
 	return image, nil
 }
 
+func vipsResize(input *C.VipsImage, hscale, vscale float64) (*C.VipsImage, error) {
+	var image *C.VipsImage
+	defer C.g_object_unref(C.gpointer(input))
+
+	err := C.vips_resize_bridge(input, &image, C.double(hscale), C.double(vscale))
+	if err != 0 {
+		return nil, catchVipsError()
+	}
+
+	return image, nil
+}
+
+// vipsUchar converts the image to 8-bit, scaling 16-bit samples down.
+func vipsUchar(input *C.VipsImage) (*C.VipsImage, error) {
+	var image *C.VipsImage
+	defer C.g_object_unref(C.gpointer(input))
+
+	err := C.vips_uchar_bridge(input, &image)
+	if err != 0 {
+		return nil, catchVipsError()
+	}
+
+	return image, nil
+}
+
+// vipsStats returns the statistics matrix of the image, with a row of
+// min, max, sum, sum2, mean, deviation, xmin, ymin, xmax and ymax
+// for all the bands followed by a row for every band.
+func vipsStats(image *C.VipsImage) ([]float64, error) {
+	var out *C.VipsImage
+
+	err := C.vips_stats_bridge(image, &out)
+	if err != 0 {
+		return nil, catchVipsError()
+	}
+	defer C.g_object_unref(C.gpointer(out))
+
+	return vipsImageDoubles(out)
+}
+
+// vipsHistFind returns the interleaved 256 bins histogram of every band of the 8-bit image.
+func vipsHistFind(image *C.VipsImage) ([]float64, error) {
+	var out *C.VipsImage
+
+	err := C.vips_hist_find_bridge(image, &out)
+	if err != 0 {
+		return nil, catchVipsError()
+	}
+	defer C.g_object_unref(C.gpointer(out))
+
+	return vipsImageDoubles(out)
+}
+
+// vipsBandDifference returns the absolute differences between the R-G and G-B bands.
+func vipsBandDifference(image *C.VipsImage) (*C.VipsImage, error) {
+	var out *C.VipsImage
+
+	err := C.vips_band_difference_bridge(image, &out)
+	if err != 0 {
+		return nil, catchVipsError()
+	}
+
+	return out, nil
+}
+
+// vipsImageDoubles renders the image and returns its pixel values as float64.
+func vipsImageDoubles(image *C.VipsImage) ([]float64, error) {
+	values := make([]float64, int(image.Xsize)*int(image.Ysize)*int(image.Bands))
+	if len(values) == 0 {
+		return values, nil
+	}
+
+	err := C.vips_image_doubles_bridge(image, (*C.double)(unsafe.Pointer(&values[0])), C.size_t(len(values)))
+	if err != 0 {
+		return nil, catchVipsError()
+	}
+
+	return values, nil
+}
+
 func vipsEmbed(input *C.VipsImage, left, top, width, height int, extend Extend, background Color) (*C.VipsImage, error) {
 	var image *C.VipsImage
 
@@ -809,6 +1295,164 @@ func vipsSharpen(image *C.VipsImage, o Sharpen) (*C.VipsImage, error) {
 	return out, nil
 }
 
+func vipsNormalize(image *C.VipsImage, clip float64) (*C.VipsImage, error) {
+	var out *C.VipsImage
+	defer C.g_object_unref(C.gpointer(image))
+
+	err := C.vips_lightness_bridge(image, &out, C.LIGHTNESS_NORMALIZE, C.double(clip), 0, 0, 0)
+	if err != 0 {
+		return nil, catchVipsError()
+	}
+	return out, nil
+}
+
+func vipsEqualize(image *C.VipsImage) (*C.VipsImage, error) {
+	var out *C.VipsImage
+	defer C.g_object_unref(C.gpointer(image))
+
+	err := C.vips_lightness_bridge(image, &out, C.LIGHTNESS_EQUALIZE, 0, 0, 0, 0)
+	if err != 0 {
+		return nil, catchVipsError()
+	}
+	return out, nil
+}
+
+func vipsCLAHE(image *C.VipsImage, o CLAHE) (*C.VipsImage, error) {
+	var out *C.VipsImage
+	defer C.g_object_unref(C.gpointer(image))
+
+	err := C.vips_lightness_bridge(image, &out, C.LIGHTNESS_CLAHE, 0, C.int(o.Width), C.int(o.Height), C.int(o.MaxSlope))
+	if err != 0 {
+		return nil, catchVipsError()
+	}
+	return out, nil
+}
+
+func vipsConvolve(image *C.VipsImage, o Convolution) (*C.VipsImage, error) {
+	var out *C.VipsImage
+	defer C.g_object_unref(C.gpointer(image))
+
+	scale := o.Scale
+	if scale == 0 {
+		for _, v := range o.Kernel {
+			scale += v
+		}
+		if scale == 0 {
+			scale = 1
+		}
+	}
+
+	kernel := make([]C.double, len(o.Kernel))
+	for i, v := range o.Kernel {
+		kernel[i] = C.double(v)
+	}
+
+	err := C.vips_convolve_bridge(image, &out, &kernel[0], C.int(o.Width), C.int(o.Height), C.double(scale), C.double(o.Offset))
+	if err != 0 {
+		return nil, catchVipsError()
+	}
+	return out, nil
+}
+
+func vipsFilter(image *C.VipsImage, filter Filter, size int) (*C.VipsImage, error) {
+	var out *C.VipsImage
+	defer C.g_object_unref(C.gpointer(image))
+
+	if size < 1 {
+		size = 3
+	}
+
+	err := C.vips_filter_bridge(image, &out, C.int(filter), C.int(size))
+	if err != 0 {
+		return nil, catchVipsError()
+	}
+	return out, nil
+}
+
+func vipsRedact(image *C.VipsImage, left, top, width, height int, mode RedactMode, block int, sigma float64) (*C.VipsImage, error) {
+	var out *C.VipsImage
+	defer C.g_object_unref(C.gpointer(image))
+
+	err := C.vips_redact_bridge(image, &out, C.int(left), C.int(top), C.int(width), C.int(height),
+		C.int(mode), C.int(block), C.double(sigma))
+	if err != 0 {
+		return nil, catchVipsError()
+	}
+	return out, nil
+}
+
+// vipsMaskFromImage returns the 8-bit opacity of the image, scaled to width x height.
+func vipsMaskFromImage(image *C.VipsImage, width, height int) (*C.VipsImage, error) {
+	var out *C.VipsImage
+	defer C.g_object_unref(C.gpointer(image))
+
+	err := C.vips_mask_from_image_bridge(image, &out, C.int(width), C.int(height))
+	if err != 0 {
+		return nil, catchVipsError()
+	}
+	return out, nil
+}
+
+// vipsApplyMask uses the 8-bit mask as the image alpha channel,
+// combined with the existing one, if any.
+func vipsApplyMask(image *C.VipsImage, mask *C.VipsImage) (*C.VipsImage, error) {
+	var out *C.VipsImage
+	defer C.g_object_unref(C.gpointer(image))
+
+	err := C.vips_apply_mask_bridge(image, mask, &out)
+	if err != 0 {
+		return nil, catchVipsError()
+	}
+	return out, nil
+}
+
+func vipsBorder(image *C.VipsImage, o Border) (*C.VipsImage, error) {
+	var out *C.VipsImage
+	defer C.g_object_unref(C.gpointer(image))
+
+	err := C.vips_border_bridge(image, &out, C.int(o.Width), C.double(o.Color.R), C.double(o.Color.G), C.double(o.Color.B))
+	if err != 0 {
+		return nil, catchVipsError()
+	}
+	return out, nil
+}
+
+func vipsPadding(image *C.VipsImage, p Padding, background Color) (*C.VipsImage, error) {
+	var out *C.VipsImage
+	defer C.g_object_unref(C.gpointer(image))
+
+	err := C.vips_padding_bridge(image, &out, C.int(p.Top), C.int(p.Right), C.int(p.Bottom), C.int(p.Left),
+		C.double(background.R), C.double(background.G), C.double(background.B))
+	if err != 0 {
+		return nil, catchVipsError()
+	}
+	return out, nil
+}
+
+func vipsShadow(image *C.VipsImage, o Shadow) (*C.VipsImage, error) {
+	var out *C.VipsImage
+	defer C.g_object_unref(C.gpointer(image))
+
+	err := C.vips_shadow_bridge(image, &out, C.double(o.Sigma), C.int(o.OffsetX), C.int(o.OffsetY),
+		C.double(o.Color.R), C.double(o.Color.G), C.double(o.Color.B), C.double(o.Opacity))
+	if err != 0 {
+		return nil, catchVipsError()
+	}
+	return out, nil
+}
+
+func vipsRemoveBackground(image *C.VipsImage, background Color, threshold, feather float64) (*C.VipsImage, error) {
+	var out *C.VipsImage
+	defer C.g_object_unref(C.gpointer(image))
+
+	err := C.vips_remove_background_bridge(image, &out, C.double(background.R), C.double(background.G), C.double(background.B),
+		C.double(threshold), C.double(feather))
+	if err != 0 {
+		return nil, catchVipsError()
+	}
+	return out, nil
+}
+
 func max(x int) int {
 	return int(math.Max(float64(x), 0))
 }
@@ -842,3 +1486,60 @@ func vipsGamma(image *C.VipsImage, Gamma float64) (*C.VipsImage, error) {
 	}
 	return out, nil
 }
+
+func vipsModulate(image *C.VipsImage, o Modulate) (*C.VipsImage, error) {
+	var out *C.VipsImage
+	defer C.g_object_unref(C.gpointer(image))
+
+	err := C.vips_modulate_bridge(image, &out, C.double(modulateFactor(o.Brightness)),
+		C.double(modulateFactor(o.Contrast)), C.double(modulateFactor(o.Saturation)), C.double(o.Hue))
+	if err != 0 {
+		return nil, catchVipsError()
+	}
+	return out, nil
+}
+
+func vipsGreyscale(image *C.VipsImage) (*C.VipsImage, error) {
+	return vipsColourFilter(image, C.COLOUR_FILTER_GREYSCALE, nil)
+}
+
+func vipsSepia(image *C.VipsImage) (*C.VipsImage, error) {
+	return vipsColourFilter(image, C.COLOUR_FILTER_SEPIA, nil)
+}
+
+func vipsTint(image *C.VipsImage, tint Color) (*C.VipsImage, error) {
+	_, a, b := colorToLab(tint)
+	return vipsColourFilter(image, C.COLOUR_FILTER_TINT, []float64{a, b})
+}
+
+func vipsDuotone(image *C.VipsImage, duotone [2]Color) (*C.VipsImage, error) {
+	shadows, highlights := duotone[0], duotone[1]
+	return vipsColourFilter(image, C.COLOUR_FILTER_DUOTONE, []float64{
+		float64(shadows.R), float64(shadows.G), float64(shadows.B),
+		float64(highlights.R), float64(highlights.G), float64(highlights.B),
+	})
+}
+
+func vipsColourFilter(image *C.VipsImage, filter C.int, params []float64) (*C.VipsImage, error) {
+	var out *C.VipsImage
+	defer C.g_object_unref(C.gpointer(image))
+
+	paramsC := make([]C.double, len(params)+1)
+	for i, p := range params {
+		paramsC[i] = C.double(p)
+	}
+
+	err := C.vips_colour_filter_bridge(image, &out, filter, &paramsC[0])
+	if err != 0 {
+		return nil, catchVipsError()
+	}
+	return out, nil
+}
+
+// modulateFactor returns the multiplier to be applied, where nil means unchanged.
+func modulateFactor(factor *float64) float64 {
+	if factor == nil {
+		return 1
+	}
+	return *factor
+}
@@ -58,6 +58,15 @@ typedef struct {
 	float    Opacity;
 } WatermarkImageOptions;
 
+typedef struct {
+	int SubsampleMode;
+	int OptimizeCoding;
+	int TrellisQuant;
+	int OvershootDeringing;
+	int OptimizeScans;
+	int QuantTable;
+} JpegSaveOptions;
+
 static unsigned long
 has_profile_embed(VipsImage *image) {
 	return vips_image_get_typeof(image, VIPS_META_ICC_NAME);
@@ -262,7 +271,9 @@ interpolator_window_size(char const *name) {
 
 const char *
 vips_enum_nick_bridge(VipsImage *image) {
-	return vips_enum_nick(VIPS_TYPE_INTERPRETATION, image->Type);
+	// The guessed interpretation sanitises the loaders output, so that
+	// CMYK images are always reported as such
+	return vips_enum_nick(VIPS_TYPE_INTERPRETATION, vips_image_guess_interpretation(image));
 }
 
 int
@@ -306,27 +317,101 @@ vips_colourspace_bridge(VipsImage *in, VipsImage **out, VipsInterpretation space
 }
 
 int
-vips_icc_transform_bridge (VipsImage *in, VipsImage **out, const char *output_icc_profile) {
+vips_icc_transform_bridge (VipsImage *in, VipsImage **out, const char *output_icc_profile, int depth) {
 	// `output_icc_profile` represents the absolute path to the output ICC profile file
+#if (VIPS_MAJOR_VERSION > 8 || (VIPS_MAJOR_VERSION == 8 && VIPS_MINOR_VERSION >= 5))
+	return vips_icc_transform(in, out, output_icc_profile, "embedded", TRUE, "depth", depth, NULL);
+#else
 	return vips_icc_transform(in, out, output_icc_profile, "embedded", TRUE, NULL);
+#endif
 }
 
 
 int
-vips_icc_transform_with_default_bridge (VipsImage *in, VipsImage **out, const char *output_icc_profile, const char *input_icc_profile) {
+vips_icc_transform_with_default_bridge (VipsImage *in, VipsImage **out, const char *output_icc_profile, const char *input_icc_profile, int depth) {
 	// `output_icc_profile` represents the absolute path to the output ICC profile file
+#if (VIPS_MAJOR_VERSION > 8 || (VIPS_MAJOR_VERSION == 8 && VIPS_MINOR_VERSION >= 5))
+	return vips_icc_transform(in, out, output_icc_profile, "input_profile", input_icc_profile, "embedded", FALSE, "depth", depth, NULL);
+#else
 	return vips_icc_transform(in, out, output_icc_profile, "input_profile", input_icc_profile, "embedded", FALSE, NULL);
+#endif
+}
+
+int
+vips_icc_transform_fallback_bridge (VipsImage *in, VipsImage **out, const char *output_icc_profile, const char *input_icc_profile, int depth) {
+	// Uses the embedded profile if any, or `input_icc_profile` otherwise
+#if (VIPS_MAJOR_VERSION > 8 || (VIPS_MAJOR_VERSION == 8 && VIPS_MINOR_VERSION >= 5))
+	return vips_icc_transform(in, out, output_icc_profile, "input_profile", input_icc_profile, "embedded", TRUE, "depth", depth, NULL);
+#else
+	return vips_icc_transform(in, out, output_icc_profile, "input_profile", input_icc_profile, "embedded", TRUE, NULL);
+#endif
+}
+
+int
+vips_profile_load_bridge (const char *name, void **data, size_t *len) {
+#if (VIPS_MAJOR_VERSION > 8 || (VIPS_MAJOR_VERSION == 8 && VIPS_MINOR_VERSION >= 8))
+	VipsBlob *blob = NULL;
+	const void *profile;
+
+	if (vips_profile_load(name, &blob, NULL)) {
+		return 1;
+	}
+	// The "none" profile loads as NULL
+	if (blob == NULL) {
+		vips_error("vips_profile_load_bridge", "no profile named %s", name);
+		return 1;
+	}
+
+	profile = vips_blob_get(blob, len);
+	*data = g_malloc(*len);
+	memcpy(*data, profile, *len);
+	vips_area_unref(VIPS_AREA(blob));
+
+	return 0;
+#else
+	vips_error("vips_profile_load_bridge", "built-in profiles require libvips 8.8+");
+	return 1;
+#endif
 }
 
 int
-vips_jpegsave_bridge(VipsImage *in, void **buf, size_t *len, int strip, int quality, int interlace) {
+vips_jpegsave_bridge(VipsImage *in, void **buf, size_t *len, int strip, int quality, int interlace, JpegSaveOptions *o) {
+#if (VIPS_MAJOR_VERSION > 8 || (VIPS_MAJOR_VERSION == 8 && VIPS_MINOR_VERSION >= 10))
+	return vips_jpegsave_buffer(in, buf, len,
+		"strip", INT_TO_GBOOLEAN(strip),
+		"Q", quality,
+		"optimize_coding", INT_TO_GBOOLEAN(o->OptimizeCoding),
+		"interlace", INT_TO_GBOOLEAN(interlace),
+		"subsample_mode", o->SubsampleMode,
+		"trellis_quant", INT_TO_GBOOLEAN(o->TrellisQuant),
+		"overshoot_deringing", INT_TO_GBOOLEAN(o->OvershootDeringing),
+		"optimize_scans", INT_TO_GBOOLEAN(o->OptimizeScans),
+		"quant_table", o->QuantTable,
+		NULL
+	);
+#elif (VIPS_MAJOR_VERSION == 8 && VIPS_MINOR_VERSION >= 5)
+	// Before 8.10, chroma subsampling can only be disabled
+	return vips_jpegsave_buffer(in, buf, len,
+		"strip", INT_TO_GBOOLEAN(strip),
+		"Q", quality,
+		"optimize_coding", INT_TO_GBOOLEAN(o->OptimizeCoding),
+		"interlace", INT_TO_GBOOLEAN(interlace),
+		"no_subsample", INT_TO_GBOOLEAN(o->SubsampleMode == 2),
+		"trellis_quant", INT_TO_GBOOLEAN(o->TrellisQuant),
+		"overshoot_deringing", INT_TO_GBOOLEAN(o->OvershootDeringing),
+		"optimize_scans", INT_TO_GBOOLEAN(o->OptimizeScans),
+		"quant_table", o->QuantTable,
+		NULL
+	);
+#else
 	return vips_jpegsave_buffer(in, buf, len,
 		"strip", INT_TO_GBOOLEAN(strip),
 		"Q", quality,
-		"optimize_coding", TRUE,
+		"optimize_coding", INT_TO_GBOOLEAN(o->OptimizeCoding),
 		"interlace", INT_TO_GBOOLEAN(interlace),
 		NULL
 	);
+#endif
 }
 
 int
@@ -373,8 +458,19 @@ vips_tiffsave_bridge(VipsImage *in, void **buf, size_t *len) {
 }
 
 int
-vips_avifsave_bridge(VipsImage *in, void **buf, size_t *len, int strip, int quality, int lossless, int speed) {
-#if (VIPS_MAJOR_VERSION > 8 || (VIPS_MAJOR_VERSION >= 8 && VIPS_MINOR_VERSION > 10) || (VIPS_MAJOR_VERSION >= 8 && VIPS_MINOR_VERSION >= 10 && VIPS_MICRO_VERSION >= 2))
+vips_avifsave_bridge(VipsImage *in, void **buf, size_t *len, int strip, int quality, int lossless, int speed, int bitdepth) {
+#if (VIPS_MAJOR_VERSION > 8 || (VIPS_MAJOR_VERSION == 8 && VIPS_MINOR_VERSION >= 15))
+    // AVIF supports up to 12 bits per sample
+    return vips_heifsave_buffer(in, buf, len,
+    "strip", INT_TO_GBOOLEAN(strip),
+    "Q", quality,
+    "lossless", INT_TO_GBOOLEAN(lossless),
+    "compression", VIPS_FOREIGN_HEIF_COMPRESSION_AV1,
+    "speed", speed,
+    "bitdepth", bitdepth > 8 ? 12 : 8,
+    NULL
+    );
+#elif (VIPS_MAJOR_VERSION > 8 || (VIPS_MAJOR_VERSION >= 8 && VIPS_MINOR_VERSION > 10) || (VIPS_MAJOR_VERSION >= 8 && VIPS_MINOR_VERSION >= 10 && VIPS_MICRO_VERSION >= 2))
     return vips_heifsave_buffer(in, buf, len,
     "strip", INT_TO_GBOOLEAN(strip),
     "Q", quality,
@@ -434,42 +530,56 @@ vips_flatten_background_brigde(VipsImage *in, VipsImage **out, double r, double
 }
 
 int
-vips_init_image (void *buf, size_t len, int imageType, VipsImage **out) {
+vips_init_image_access (void *buf, size_t len, int imageType, VipsAccess access, VipsImage **out) {
 	int code = 1;
 
 	if (imageType == JPEG) {
-		code = vips_jpegload_buffer(buf, len, out, "access", VIPS_ACCESS_RANDOM, NULL);
+		code = vips_jpegload_buffer(buf, len, out, "access", access, NULL);
 	} else if (imageType == PNG) {
-		code = vips_pngload_buffer(buf, len, out, "access", VIPS_ACCESS_RANDOM, NULL);
+		code = vips_pngload_buffer(buf, len, out, "access", access, NULL);
 	} else if (imageType == WEBP) {
-		code = vips_webpload_buffer(buf, len, out, "access", VIPS_ACCESS_RANDOM, NULL);
+		code = vips_webpload_buffer(buf, len, out, "access", access, NULL);
 	} else if (imageType == TIFF) {
-		code = vips_tiffload_buffer(buf, len, out, "access", VIPS_ACCESS_RANDOM, NULL);
+		code = vips_tiffload_buffer(buf, len, out, "access", access, NULL);
 #if (VIPS_MAJOR_VERSION >= 8)
 #if (VIPS_MINOR_VERSION >= 3)
 	} else if (imageType == GIF) {
-		code = vips_gifload_buffer(buf, len, out, "access", VIPS_ACCESS_RANDOM, NULL);
+		code = vips_gifload_buffer(buf, len, out, "access", access, NULL);
 	} else if (imageType == PDF) {
-		code = vips_pdfload_buffer(buf, len, out, "access", VIPS_ACCESS_RANDOM, NULL);
+		code = vips_pdfload_buffer(buf, len, out, "access", access, NULL);
 	} else if (imageType == SVG) {
-		code = vips_svgload_buffer(buf, len, out, "access", VIPS_ACCESS_RANDOM, NULL);
+		code = vips_svgload_buffer(buf, len, out, "access", access, NULL);
 #endif
 	} else if (imageType == MAGICK) {
-		code = vips_magickload_buffer(buf, len, out, "access", VIPS_ACCESS_RANDOM, NULL);
+		code = vips_magickload_buffer(buf, len, out, "access", access, NULL);
 #endif
 #if (VIPS_MAJOR_VERSION > 8 || (VIPS_MAJOR_VERSION == 8 && VIPS_MINOR_VERSION >= 8))
 	} else if (imageType == HEIF) {
-		code = vips_heifload_buffer(buf, len, out, "access", VIPS_ACCESS_RANDOM, NULL);
+		code = vips_heifload_buffer(buf, len, out, "access", access, NULL);
 #endif
 #if (VIPS_MAJOR_VERSION == 8 && VIPS_MINOR_VERSION >= 9)
 	} else if (imageType == AVIF) {
-		code = vips_heifload_buffer(buf, len, out, "access", VIPS_ACCESS_RANDOM, NULL);
+		code = vips_heifload_buffer(buf, len, out, "access", access, NULL);
 #endif
 	}
 
 	return code;
 }
 
+int
+vips_init_image (void *buf, size_t len, int imageType, VipsImage **out) {
+	return vips_init_image_access(buf, len, imageType, VIPS_ACCESS_RANDOM, out);
+}
+
+int
+vips_n_pages_bridge (VipsImage *in) {
+	int n_pages = 1;
+	if (vips_image_get_typeof(in, "n-pages") != 0) {
+		vips_image_get_int(in, "n-pages", &n_pages);
+	}
+	return n_pages;
+}
+
 int
 vips_watermark_replicate (VipsImage *orig, VipsImage *in, VipsImage **out) {
 	VipsImage *cache = vips_image_new();
@@ -660,3 +770,1002 @@ int vips_gamma_bridge(VipsImage *in, VipsImage **out, double exponent)
 {
   return vips_gamma(in, out, "exponent", 1.0 / exponent, NULL);
 }
+
+int
+vips_ssim_bridge(VipsImage *a, VipsImage *b, double *out) {
+	// Stabilization constants for 8-bit images, as defined by the SSIM paper
+	double c1 = (0.01 * 255) * (0.01 * 255);
+	double c2 = (0.03 * 255) * (0.03 * 255);
+
+	VipsImage *base = vips_image_new();
+	VipsImage **t = (VipsImage **) vips_object_local_array(VIPS_OBJECT(base), 29);
+
+	// Compare the luminance of both images
+	if (
+		vips_colourspace(a, &t[0], VIPS_INTERPRETATION_B_W, NULL) ||
+		vips_extract_band(t[0], &t[1], 0, NULL) ||
+		vips_cast(t[1], &t[2], VIPS_FORMAT_FLOAT, NULL) ||
+		vips_colourspace(b, &t[3], VIPS_INTERPRETATION_B_W, NULL) ||
+		vips_extract_band(t[3], &t[4], 0, NULL) ||
+		vips_cast(t[4], &t[5], VIPS_FORMAT_FLOAT, NULL)
+	) {
+		g_object_unref(base);
+		return 1;
+	}
+
+	// Local means, variances and covariance over a gaussian window
+	if (
+		vips_gaussblur(t[2], &t[6], 1.5, NULL) ||
+		vips_gaussblur(t[5], &t[7], 1.5, NULL) ||
+		vips_multiply(t[2], t[2], &t[8], NULL) ||
+		vips_multiply(t[5], t[5], &t[9], NULL) ||
+		vips_multiply(t[2], t[5], &t[10], NULL) ||
+		vips_gaussblur(t[8], &t[11], 1.5, NULL) ||
+		vips_gaussblur(t[9], &t[12], 1.5, NULL) ||
+		vips_gaussblur(t[10], &t[13], 1.5, NULL) ||
+		vips_multiply(t[6], t[6], &t[14], NULL) ||
+		vips_multiply(t[7], t[7], &t[15], NULL) ||
+		vips_multiply(t[6], t[7], &t[16], NULL) ||
+		vips_subtract(t[11], t[14], &t[17], NULL) ||
+		vips_subtract(t[12], t[15], &t[18], NULL) ||
+		vips_subtract(t[13], t[16], &t[19], NULL)
+	) {
+		g_object_unref(base);
+		return 1;
+	}
+
+	// ((2 * mu_a * mu_b + c1) * (2 * sigma_ab + c2)) /
+	// ((mu_a^2 + mu_b^2 + c1) * (sigma_a^2 + sigma_b^2 + c2))
+	if (
+		vips_linear1(t[16], &t[20], 2.0, c1, NULL) ||
+		vips_linear1(t[19], &t[21], 2.0, c2, NULL) ||
+		vips_multiply(t[20], t[21], &t[22], NULL) ||
+		vips_add(t[14], t[15], &t[23], NULL) ||
+		vips_linear1(t[23], &t[24], 1.0, c1, NULL) ||
+		vips_add(t[17], t[18], &t[25], NULL) ||
+		vips_linear1(t[25], &t[26], 1.0, c2, NULL) ||
+		vips_multiply(t[24], t[26], &t[27], NULL) ||
+		vips_divide(t[22], t[27], &t[28], NULL) ||
+		vips_avg(t[28], out, NULL)
+	) {
+		g_object_unref(base);
+		return 1;
+	}
+
+	g_object_unref(base);
+	return 0;
+}
+
+int
+vips_resize_bridge(VipsImage *in, VipsImage **out, double hscale, double vscale) {
+	return vips_resize(in, out, hscale, "vscale", vscale, NULL);
+}
+
+int
+vips_compare_prepare_bridge(VipsImage *in, VipsImage **out) {
+	VipsImage *base = vips_image_new();
+	VipsImage **t = (VipsImage **) vips_object_local_array(VIPS_OBJECT(base), 3);
+
+	// Flatten transparent images over white, as they are usually displayed
+	if (has_alpha_channel(in) == 1) {
+		if (vips_flatten_background_brigde(in, &t[0], 255.0, 255.0, 255.0)) {
+			g_object_unref(base);
+			return 1;
+		}
+	} else {
+		t[0] = in;
+		g_object_ref(in);
+	}
+
+	if (
+		vips_colourspace(t[0], &t[1], VIPS_INTERPRETATION_sRGB, NULL) ||
+		vips_cast(t[1], out, VIPS_FORMAT_UCHAR, NULL)
+	) {
+		g_object_unref(base);
+		return 1;
+	}
+
+	g_object_unref(base);
+	return 0;
+}
+
+int
+vips_compare_bridge(VipsImage *a, VipsImage *b, double *mse, double *max_diff) {
+	VipsImage *base = vips_image_new();
+	VipsImage **t = (VipsImage **) vips_object_local_array(VIPS_OBJECT(base), 3);
+
+	if (
+		vips_subtract(a, b, &t[0], NULL) ||
+		vips_abs(t[0], &t[1], NULL) ||
+		vips_max(t[1], max_diff, NULL) ||
+		vips_multiply(t[0], t[0], &t[2], NULL) ||
+		vips_avg(t[2], mse, NULL)
+	) {
+		g_object_unref(base);
+		return 1;
+	}
+
+	g_object_unref(base);
+	return 0;
+}
+
+int
+vips_diff_image_bridge(VipsImage *a, VipsImage *b, VipsImage **out) {
+	double ones[3] = { 1, 1, 1 };
+	double red[3] = { 255, 0, 0 };
+
+	VipsImage *base = vips_image_new();
+	VipsImage **t = (VipsImage **) vips_object_local_array(VIPS_OBJECT(base), 11);
+
+	// Mask of the pixels which changed in any band
+	if (
+		vips_subtract(a, b, &t[0], NULL) ||
+		vips_abs(t[0], &t[1], NULL) ||
+		vips_more_const1(t[1], &t[2], 0.0, NULL) ||
+		vips_bandbool(t[2], &t[3], VIPS_OPERATION_BOOLEAN_OR, NULL)
+	) {
+		g_object_unref(base);
+		return 1;
+	}
+
+	// Faded greyscale version of the first image as context
+	if (
+		vips_colourspace(a, &t[4], VIPS_INTERPRETATION_B_W, NULL) ||
+		vips_linear1(t[4], &t[5], 0.3, 178.5, NULL) ||
+		vips_cast(t[5], &t[6], VIPS_FORMAT_UCHAR, NULL)
+	) {
+		g_object_unref(base);
+		return 1;
+	}
+
+	// Paint the changed pixels in red
+	if (
+		vips_black(&t[7], a->Xsize, a->Ysize, NULL) ||
+		vips_linear(t[7], &t[8], ones, red, 3, NULL) ||
+		vips_cast(t[8], &t[9], VIPS_FORMAT_UCHAR, NULL) ||
+		vips_ifthenelse(t[3], t[9], t[6], &t[10], NULL) ||
+		vips_copy(t[10], out, "interpretation", VIPS_INTERPRETATION_sRGB, NULL)
+	) {
+		g_object_unref(base);
+		return 1;
+	}
+
+	g_object_unref(base);
+	return 0;
+}
+
+int
+vips_cast_bridge(VipsImage *in, VipsImage **out, VipsBandFormat format) {
+	return vips_cast(in, out, format, NULL);
+}
+
+int
+vips_image_from_memory_bridge(void *data, size_t len, int width, int height, int bands, VipsImage **out) {
+	VipsImage *base = vips_image_new();
+	VipsImage **t = (VipsImage **) vips_object_local_array(VIPS_OBJECT(base), 1);
+
+	t[0] = vips_image_new_from_memory_copy(data, len, width, height, bands, VIPS_FORMAT_UCHAR);
+	if (
+		t[0] == NULL ||
+		vips_copy(t[0], out, "interpretation", bands >= 3 ? VIPS_INTERPRETATION_sRGB : VIPS_INTERPRETATION_B_W, NULL)
+	) {
+		g_object_unref(base);
+		return 1;
+	}
+
+	g_object_unref(base);
+	return 0;
+}
+
+int
+vips_uchar_bridge(VipsImage *in, VipsImage **out) {
+	VipsImage *base = vips_image_new();
+	VipsImage **t = (VipsImage **) vips_object_local_array(VIPS_OBJECT(base), 1);
+
+	// Scale 16-bit samples down instead of clipping them
+	if (in->BandFmt == VIPS_FORMAT_USHORT) {
+		if (
+			vips_rshift_const1(in, &t[0], 8, NULL) ||
+			vips_cast(t[0], out, VIPS_FORMAT_UCHAR, NULL)
+		) {
+			g_object_unref(base);
+			return 1;
+		}
+	} else if (vips_cast(in, out, VIPS_FORMAT_UCHAR, NULL)) {
+		g_object_unref(base);
+		return 1;
+	}
+
+	g_object_unref(base);
+	return 0;
+}
+
+int
+vips_stats_bridge(VipsImage *in, VipsImage **out) {
+	return vips_stats(in, out, NULL);
+}
+
+int
+vips_hist_find_bridge(VipsImage *in, VipsImage **out) {
+	return vips_hist_find(in, out, NULL);
+}
+
+int
+vips_band_difference_bridge(VipsImage *in, VipsImage **out) {
+	VipsImage *base = vips_image_new();
+	VipsImage **t = (VipsImage **) vips_object_local_array(VIPS_OBJECT(base), 7);
+
+	// Absolute differences between the R-G and G-B bands
+	if (
+		vips_extract_band(in, &t[0], 0, NULL) ||
+		vips_extract_band(in, &t[1], 1, NULL) ||
+		vips_extract_band(in, &t[2], 2, NULL) ||
+		vips_subtract(t[0], t[1], &t[3], NULL) ||
+		vips_abs(t[3], &t[4], NULL) ||
+		vips_subtract(t[1], t[2], &t[5], NULL) ||
+		vips_abs(t[5], &t[6], NULL) ||
+		vips_bandjoin2(t[4], t[6], out, NULL)
+	) {
+		g_object_unref(base);
+		return 1;
+	}
+
+	g_object_unref(base);
+	return 0;
+}
+
+int
+vips_image_doubles_bridge(VipsImage *in, double *out, size_t n) {
+	VipsImage *t;
+	void *data;
+	size_t size;
+
+	if (vips_cast(in, &t, VIPS_FORMAT_DOUBLE, NULL)) {
+		return 1;
+	}
+
+	data = vips_image_write_to_memory(t, &size);
+	g_object_unref(t);
+	if (data == NULL) {
+		return 1;
+	}
+
+	memcpy(out, data, VIPS_MIN(size, n * sizeof(double)));
+	g_free(data);
+	return 0;
+}
+
+enum lightness_ops {
+	LIGHTNESS_NORMALIZE = 0,
+	LIGHTNESS_EQUALIZE,
+	LIGHTNESS_CLAHE
+};
+
+static int
+vips_lightness_op(VipsImage *in, VipsImage **out, int op, double clip, int width, int height, int max_slope) {
+	double low, high;
+
+	switch (op) {
+	case LIGHTNESS_NORMALIZE:
+		if (clip > 0) {
+			if (vips_percent(in, clip, &low, NULL) || vips_percent(in, 100 - clip, &high, NULL)) {
+				return 1;
+			}
+		} else if (vips_min(in, &low, NULL) || vips_max(in, &high, NULL)) {
+			return 1;
+		}
+		if (high <= low) {
+			return vips_copy(in, out, NULL);
+		}
+		return vips_linear1(in, out, 255.0 / (high - low), -low * 255.0 / (high - low), "uchar", TRUE, NULL);
+	case LIGHTNESS_EQUALIZE:
+		return vips_hist_equal(in, out, NULL);
+	case LIGHTNESS_CLAHE:
+#if (VIPS_MAJOR_VERSION > 8 || (VIPS_MAJOR_VERSION == 8 && VIPS_MINOR_VERSION >= 5))
+		return vips_hist_local(in, out, width, height, "max_slope", max_slope, NULL);
+#else
+		return vips_hist_local(in, out, width, height, NULL);
+#endif
+	}
+
+	vips_error("bimg", "unsupported lightness operation");
+	return 1;
+}
+
+int
+vips_lightness_bridge(VipsImage *in, VipsImage **out, int op, double clip, int width, int height, int max_slope) {
+	VipsInterpretation interpretation = vips_image_guess_interpretation(in);
+	VipsBandFormat format = in->BandFmt;
+
+	VipsImage *base = vips_image_new();
+	VipsImage **t = (VipsImage **) vips_object_local_array(VIPS_OBJECT(base), 13);
+
+	// Keep the alpha channel aside
+	if (has_alpha_channel(in) == 1) {
+		if (
+			vips_extract_band(in, &t[0], 0, "n", in->Bands - 1, NULL) ||
+			vips_extract_band(in, &t[1], in->Bands - 1, NULL)
+		) {
+			g_object_unref(base);
+			return 1;
+		}
+	} else {
+		t[0] = in;
+		g_object_ref(in);
+	}
+
+	// Process the L channel as 8-bit, leaving the colours untouched
+	if (
+		vips_colourspace(t[0], &t[2], VIPS_INTERPRETATION_LAB, NULL) ||
+		vips_extract_band(t[2], &t[3], 0, NULL) ||
+		vips_extract_band(t[2], &t[4], 1, "n", 2, NULL) ||
+		vips_linear1(t[3], &t[5], 255.0 / 100.0, 0.0, "uchar", TRUE, NULL) ||
+		vips_lightness_op(t[5], &t[6], op, clip, width, height, max_slope) ||
+		vips_linear1(t[6], &t[7], 100.0 / 255.0, 0.0, NULL) ||
+		vips_bandjoin2(t[7], t[4], &t[8], NULL) ||
+		vips_copy(t[8], &t[9], "interpretation", VIPS_INTERPRETATION_LAB, NULL) ||
+		vips_colourspace(t[9], &t[10], interpretation, NULL) ||
+		vips_cast(t[10], &t[11], format, NULL)
+	) {
+		g_object_unref(base);
+		return 1;
+	}
+
+	if (t[1] != NULL) {
+		if (vips_bandjoin2(t[11], t[1], out, NULL)) {
+			g_object_unref(base);
+			return 1;
+		}
+	} else {
+		*out = t[11];
+		g_object_ref(t[11]);
+	}
+
+	g_object_unref(base);
+	return 0;
+}
+
+int
+vips_modulate_bridge(VipsImage *in, VipsImage **out, double brightness, double contrast, double saturation, double hue) {
+	VipsInterpretation interpretation = vips_image_guess_interpretation(in);
+	VipsBandFormat format = in->BandFmt;
+
+	// L' = (L * brightness - 50) * contrast + 50, C' = C * saturation, h' = h + hue
+	double a[3] = { brightness * contrast, saturation, 1.0 };
+	double b[3] = { 50.0 * (1.0 - contrast), 0.0, hue };
+
+	VipsImage *base = vips_image_new();
+	VipsImage **t = (VipsImage **) vips_object_local_array(VIPS_OBJECT(base), 6);
+
+	// Keep the alpha channel aside
+	if (has_alpha_channel(in) == 1) {
+		if (
+			vips_extract_band(in, &t[0], 0, "n", in->Bands - 1, NULL) ||
+			vips_extract_band(in, &t[1], in->Bands - 1, NULL)
+		) {
+			g_object_unref(base);
+			return 1;
+		}
+	} else {
+		t[0] = in;
+		g_object_ref(in);
+	}
+
+	if (
+		vips_colourspace(t[0], &t[2], VIPS_INTERPRETATION_LCH, NULL) ||
+		vips_linear(t[2], &t[3], a, b, 3, NULL) ||
+		vips_colourspace(t[3], &t[4], interpretation, NULL) ||
+		vips_cast(t[4], &t[5], format, NULL)
+	) {
+		g_object_unref(base);
+		return 1;
+	}
+
+	if (t[1] != NULL) {
+		if (vips_bandjoin2(t[5], t[1], out, NULL)) {
+			g_object_unref(base);
+			return 1;
+		}
+	} else {
+		*out = t[5];
+		g_object_ref(t[5]);
+	}
+
+	g_object_unref(base);
+	return 0;
+}
+
+enum colour_filters {
+	COLOUR_FILTER_GREYSCALE = 0,
+	COLOUR_FILTER_SEPIA,
+	COLOUR_FILTER_TINT,
+	COLOUR_FILTER_DUOTONE
+};
+
+static int
+vips_colour_filter(VipsImage *in, VipsImage **out, int filter, double *params, VipsObject *scope) {
+	double sepia[9] = {
+		0.393, 0.769, 0.189,
+		0.349, 0.686, 0.168,
+		0.272, 0.534, 0.131
+	};
+	double a[3], b[3];
+	int i;
+
+	VipsImage **t = (VipsImage **) vips_object_local_array(scope, 5);
+
+	switch (filter) {
+	case COLOUR_FILTER_GREYSCALE:
+		return vips_colourspace(in, &t[0], VIPS_INTERPRETATION_B_W, NULL) ||
+			vips_colourspace(t[0], out, VIPS_INTERPRETATION_sRGB, NULL);
+	case COLOUR_FILTER_SEPIA:
+		t[0] = vips_image_new_matrix_from_array(3, 3, sepia, 9);
+		return t[0] == NULL || vips_recomb(in, out, t[0], NULL);
+	case COLOUR_FILTER_TINT:
+		// Keep the lightness, replacing the a and b chroma with the tint ones
+		return vips_colourspace(in, &t[0], VIPS_INTERPRETATION_LAB, NULL) ||
+			vips_extract_band(t[0], &t[1], 0, NULL) ||
+			vips_bandjoin_const(t[1], &t[2], params, 2, NULL) ||
+			vips_copy(t[2], &t[3], "interpretation", VIPS_INTERPRETATION_LAB, NULL) ||
+			vips_colourspace(t[3], out, VIPS_INTERPRETATION_sRGB, NULL);
+	case COLOUR_FILTER_DUOTONE:
+		// Map the luminance from the shadows to the highlights colour
+		for (i = 0; i < 3; i++) {
+			a[i] = (params[i + 3] - params[i]) / 255.0;
+			b[i] = params[i];
+		}
+		return vips_colourspace(in, &t[0], VIPS_INTERPRETATION_B_W, NULL) ||
+			vips_linear(t[0], out, a, b, 3, NULL);
+	}
+
+	vips_error("bimg", "unsupported colour filter");
+	return 1;
+}
+
+int
+vips_colour_filter_bridge(VipsImage *in, VipsImage **out, int filter, double *params) {
+	VipsImage *base = vips_image_new();
+	VipsImage **t = (VipsImage **) vips_object_local_array(VIPS_OBJECT(base), 7);
+
+	if (
+		vips_colourspace(in, &t[0], VIPS_INTERPRETATION_sRGB, NULL) ||
+		vips_cast(t[0], &t[1], VIPS_FORMAT_UCHAR, NULL)
+	) {
+		g_object_unref(base);
+		return 1;
+	}
+
+	// Keep the alpha channel aside
+	if (has_alpha_channel(t[1]) == 1) {
+		if (
+			vips_extract_band(t[1], &t[2], 0, "n", t[1]->Bands - 1, NULL) ||
+			vips_extract_band(t[1], &t[3], t[1]->Bands - 1, NULL)
+		) {
+			g_object_unref(base);
+			return 1;
+		}
+	} else {
+		t[2] = t[1];
+		g_object_ref(t[1]);
+	}
+
+	if (
+		vips_colour_filter(t[2], &t[4], filter, params, VIPS_OBJECT(base)) ||
+		vips_cast(t[4], &t[5], VIPS_FORMAT_UCHAR, NULL) ||
+		vips_copy(t[5], &t[6], "interpretation", VIPS_INTERPRETATION_sRGB, NULL)
+	) {
+		g_object_unref(base);
+		return 1;
+	}
+
+	if (t[3] != NULL) {
+		if (vips_bandjoin2(t[6], t[3], out, NULL)) {
+			g_object_unref(base);
+			return 1;
+		}
+	} else {
+		*out = t[6];
+		g_object_ref(t[6]);
+	}
+
+	g_object_unref(base);
+	return 0;
+}
+
+int
+vips_convolve_bridge(VipsImage *in, VipsImage **out, double *kernel, int width, int height, double scale, double offset) {
+	VipsImage *base = vips_image_new();
+	VipsImage **t = (VipsImage **) vips_object_local_array(VIPS_OBJECT(base), 2);
+
+	t[0] = vips_image_new_matrix_from_array(width, height, kernel, width * height);
+	if (t[0] == NULL) {
+		g_object_unref(base);
+		return 1;
+	}
+	vips_image_set_double(t[0], "scale", scale);
+	vips_image_set_double(t[0], "offset", offset);
+
+	if (
+		vips_conv(in, &t[1], t[0], NULL) ||
+		vips_cast(t[1], out, in->BandFmt, NULL)
+	) {
+		g_object_unref(base);
+		return 1;
+	}
+
+	g_object_unref(base);
+	return 0;
+}
+
+enum filters {
+	FILTER_NONE = 0,
+	FILTER_EMBOSS,
+	FILTER_SOBEL,
+	FILTER_CANNY,
+	FILTER_BOX_BLUR,
+	FILTER_MEDIAN,
+	FILTER_DETAIL
+};
+
+int
+vips_filter_bridge(VipsImage *in, VipsImage **out, int filter, int size) {
+	double emboss[9] = {
+		-2, -1, 0,
+		-1, 1, 1,
+		0, 1, 2
+	};
+	double sobel_x[9] = {
+		-1, 0, 1,
+		-2, 0, 2,
+		-1, 0, 1
+	};
+	double sobel_y[9] = {
+		-1, -2, -1,
+		0, 0, 0,
+		1, 2, 1
+	};
+	double *box;
+	int i, err;
+
+	VipsImage *base = vips_image_new();
+	VipsImage **t = (VipsImage **) vips_object_local_array(VIPS_OBJECT(base), 9);
+
+	switch (filter) {
+	case FILTER_EMBOSS:
+		t[0] = vips_image_new_matrix_from_array(3, 3, emboss, 9);
+		err = t[0] == NULL ||
+			vips_conv(in, &t[1], t[0], NULL) ||
+			vips_cast(t[1], out, in->BandFmt, NULL);
+		break;
+	case FILTER_SOBEL:
+		// Gradient magnitude of the luminance, approximated as |Gx| + |Gy|
+		t[0] = vips_image_new_matrix_from_array(3, 3, sobel_x, 9);
+		t[1] = vips_image_new_matrix_from_array(3, 3, sobel_y, 9);
+		err = t[0] == NULL || t[1] == NULL ||
+			vips_colourspace(in, &t[2], VIPS_INTERPRETATION_B_W, NULL) ||
+			vips_extract_band(t[2], &t[3], 0, NULL) ||
+			vips_conv(t[3], &t[4], t[0], NULL) ||
+			vips_conv(t[3], &t[5], t[1], NULL) ||
+			vips_abs(t[4], &t[6], NULL) ||
+			vips_abs(t[5], &t[7], NULL) ||
+			vips_add(t[6], t[7], &t[8], NULL) ||
+			vips_cast(t[8], out, VIPS_FORMAT_UCHAR, NULL);
+		break;
+	case FILTER_CANNY:
+#if (VIPS_MAJOR_VERSION > 8 || (VIPS_MAJOR_VERSION == 8 && VIPS_MINOR_VERSION >= 8))
+		err = vips_colourspace(in, &t[0], VIPS_INTERPRETATION_B_W, NULL) ||
+			vips_extract_band(t[0], &t[1], 0, NULL) ||
+			vips_canny(t[1], &t[2], NULL) ||
+			vips_cast(t[2], out, VIPS_FORMAT_UCHAR, NULL);
+#else
+		vips_error("bimg", "canny edge detection requires libvips 8.8+");
+		err = 1;
+#endif
+		break;
+	case FILTER_BOX_BLUR:
+		box = g_new(double, size * size);
+		for (i = 0; i < size * size; i++) {
+			box[i] = 1.0;
+		}
+		t[0] = vips_image_new_matrix_from_array(size, size, box, size * size);
+		g_free(box);
+		if (t[0] != NULL) {
+			vips_image_set_double(t[0], "scale", size * size);
+		}
+		err = t[0] == NULL ||
+			vips_conv(in, &t[1], t[0], NULL) ||
+			vips_cast(t[1], out, in->BandFmt, NULL);
+		break;
+	case FILTER_MEDIAN:
+		err = vips_median(in, out, size, NULL);
+		break;
+	case FILTER_DETAIL:
+		// Unsharp masking: in + (in - blur)
+		err = vips_gaussblur_bridge(in, &t[0], 1.0, 0.2) ||
+			vips_subtract(in, t[0], &t[1], NULL) ||
+			vips_add(in, t[1], &t[2], NULL) ||
+			vips_cast(t[2], out, in->BandFmt, NULL);
+		break;
+	default:
+		vips_error("bimg", "unsupported filter");
+		err = 1;
+	}
+
+	g_object_unref(base);
+	return err;
+}
+
+enum redact_modes {
+	REDACT_PIXELATE = 0,
+	REDACT_BLUR
+};
+
+int
+vips_redact_bridge(VipsImage *in, VipsImage **out, int left, int top, int width, int height, int mode, int block, double sigma) {
+	VipsImage *base = vips_image_new();
+	VipsImage **t = (VipsImage **) vips_object_local_array(VIPS_OBJECT(base), 5);
+
+	if (vips_extract_area(in, &t[0], left, top, width, height, NULL)) {
+		g_object_unref(base);
+		return 1;
+	}
+
+	switch (mode) {
+	case REDACT_PIXELATE:
+		// Average every block, then scale it back up as a single colour
+		if (
+			vips_shrink_bridge(t[0], &t[1], (double) block, (double) block) ||
+			vips_zoom_bridge(t[1], &t[2], block, block) ||
+			vips_embed(t[2], &t[3], 0, 0, width, height, "extend", VIPS_EXTEND_COPY, NULL)
+		) {
+			g_object_unref(base);
+			return 1;
+		}
+		break;
+	case REDACT_BLUR:
+		if (vips_gaussblur_bridge(t[0], &t[3], sigma, 0.2)) {
+			g_object_unref(base);
+			return 1;
+		}
+		break;
+	default:
+		vips_error("bimg", "unsupported redact mode");
+		g_object_unref(base);
+		return 1;
+	}
+
+	if (
+		vips_cast(t[3], &t[4], in->BandFmt, NULL) ||
+		vips_insert(in, t[4], out, left, top, NULL)
+	) {
+		g_object_unref(base);
+		return 1;
+	}
+
+	g_object_unref(base);
+	return 0;
+}
+
+int
+vips_mask_from_image_bridge(VipsImage *in, VipsImage **out, int width, int height) {
+	VipsImage *base = vips_image_new();
+	VipsImage **t = (VipsImage **) vips_object_local_array(VIPS_OBJECT(base), 4);
+
+	// Use the alpha channel as mask, or the luminance of opaque images
+	if (has_alpha_channel(in) == 1) {
+		if (vips_extract_band(in, &t[0], in->Bands - 1, NULL)) {
+			g_object_unref(base);
+			return 1;
+		}
+	} else if (
+		vips_colourspace(in, &t[1], VIPS_INTERPRETATION_B_W, NULL) ||
+		vips_extract_band(t[1], &t[0], 0, NULL)
+	) {
+		g_object_unref(base);
+		return 1;
+	}
+
+	if (
+		vips_uchar_bridge(t[0], &t[2]) ||
+		vips_resize_bridge(t[2], &t[3], (double) width / t[2]->Xsize, (double) height / t[2]->Ysize) ||
+		vips_embed(t[3], out, 0, 0, width, height, "extend", VIPS_EXTEND_COPY, NULL)
+	) {
+		g_object_unref(base);
+		return 1;
+	}
+
+	g_object_unref(base);
+	return 0;
+}
+
+int
+vips_apply_mask_bridge(VipsImage *in, VipsImage *mask, VipsImage **out) {
+	// Scale the 8-bit mask to the range of 16-bit images
+	double scale = in->BandFmt == VIPS_FORMAT_USHORT ? 257.0 : 1.0;
+
+	VipsImage *base = vips_image_new();
+	VipsImage **t = (VipsImage **) vips_object_local_array(VIPS_OBJECT(base), 5);
+
+	if (has_alpha_channel(in) == 1) {
+		// Combine with the existing alpha channel
+		if (
+			vips_extract_band(in, &t[0], 0, "n", in->Bands - 1, NULL) ||
+			vips_extract_band(in, &t[1], in->Bands - 1, NULL) ||
+			vips_multiply(t[1], mask, &t[2], NULL) ||
+			vips_linear1(t[2], &t[3], 1.0 / 255.0, 0.0, NULL) ||
+			vips_cast(t[3], &t[4], in->BandFmt, NULL) ||
+			vips_bandjoin2(t[0], t[4], out, NULL)
+		) {
+			g_object_unref(base);
+			return 1;
+		}
+	} else if (
+		vips_linear1(mask, &t[0], scale, 0.0, NULL) ||
+		vips_cast(t[0], &t[1], in->BandFmt, NULL) ||
+		vips_bandjoin2(in, t[1], out, NULL)
+	) {
+		g_object_unref(base);
+		return 1;
+	}
+
+	g_object_unref(base);
+	return 0;
+}
+
+int
+vips_border_bridge(VipsImage *in, VipsImage **out, int width, double r, double g, double b) {
+	int is_16bit = vips_is_16bit(in->Type);
+	double max_alpha = is_16bit ? 65535.0 : 255.0;
+	double background[4] = { r, g, b, max_alpha };
+	VipsArrayDouble *vipsBackground;
+	int err;
+
+	VipsImage *base = vips_image_new();
+	VipsImage **t = (VipsImage **) vips_object_local_array(VIPS_OBJECT(base), 1);
+
+	// The colour is given in 8-bit
+	if (is_16bit) {
+		background[0] = 65535 * r / 255;
+		background[1] = 65535 * g / 255;
+		background[2] = 65535 * b / 255;
+	}
+
+	// The border colour needs the colour bands
+	if (in->Bands < 3) {
+		if (vips_colourspace(in, &t[0], is_16bit ? VIPS_INTERPRETATION_RGB16 : VIPS_INTERPRETATION_sRGB, NULL)) {
+			g_object_unref(base);
+			return 1;
+		}
+	} else {
+		t[0] = in;
+		g_object_ref(in);
+	}
+
+	// Opaque border, even around transparent images
+	vipsBackground = vips_array_double_new(background, has_alpha_channel(t[0]) == 1 ? 4 : 3);
+	err = vips_embed(t[0], out, width, width, t[0]->Xsize + 2 * width, t[0]->Ysize + 2 * width,
+		"extend", VIPS_EXTEND_BACKGROUND, "background", vipsBackground, NULL);
+	vips_area_unref(VIPS_AREA(vipsBackground));
+
+	g_object_unref(base);
+	return err;
+}
+
+int
+vips_padding_bridge(VipsImage *in, VipsImage **out, int top, int right, int bottom, int left, double r, double g, double b) {
+	int is_16bit = vips_is_16bit(in->Type);
+	double background[4] = { r, g, b, 0.0 };
+	VipsArrayDouble *vipsBackground;
+	int err;
+
+	VipsImage *base = vips_image_new();
+	VipsImage **t = (VipsImage **) vips_object_local_array(VIPS_OBJECT(base), 1);
+
+	// The colour is given in 8-bit
+	if (is_16bit) {
+		background[0] = 65535 * r / 255;
+		background[1] = 65535 * g / 255;
+		background[2] = 65535 * b / 255;
+	}
+
+	// The background colour needs the colour bands
+	if (in->Bands < 3) {
+		if (vips_colourspace(in, &t[0], is_16bit ? VIPS_INTERPRETATION_RGB16 : VIPS_INTERPRETATION_sRGB, NULL)) {
+			g_object_unref(base);
+			return 1;
+		}
+	} else {
+		t[0] = in;
+		g_object_ref(in);
+	}
+
+	// Transparent padding around transparent images, as with Embed
+	vipsBackground = vips_array_double_new(background, has_alpha_channel(t[0]) == 1 ? 4 : 3);
+	err = vips_embed(t[0], out, left, top, t[0]->Xsize + left + right, t[0]->Ysize + top + bottom,
+		"extend", VIPS_EXTEND_BACKGROUND, "background", vipsBackground, NULL);
+	vips_area_unref(VIPS_AREA(vipsBackground));
+
+	g_object_unref(base);
+	return err;
+}
+
+int
+vips_shadow_bridge(VipsImage *in, VipsImage **out, double sigma, int offset_x, int offset_y, double r, double g, double b, double opacity) {
+#if (VIPS_MAJOR_VERSION > 8 || (VIPS_MAJOR_VERSION == 8 && VIPS_MINOR_VERSION >= 6))
+	// Keep 16-bit images in 16-bit, scaling the 8-bit colour
+	int is_16bit = vips_is_16bit(in->Type);
+	double max_alpha = is_16bit ? 65535.0 : 255.0;
+	VipsInterpretation interpretation = is_16bit ? VIPS_INTERPRETATION_RGB16 : VIPS_INTERPRETATION_sRGB;
+	VipsBandFormat format = is_16bit ? VIPS_FORMAT_USHORT : VIPS_FORMAT_UCHAR;
+	double colour[3] = { r * max_alpha / 255, g * max_alpha / 255, b * max_alpha / 255 };
+	double zeros[3] = { 0.0, 0.0, 0.0 };
+	int margin = (int) ceil(3.0 * sigma) + VIPS_MAX(abs(offset_x), abs(offset_y));
+	int width = in->Xsize + 2 * margin;
+	int height = in->Ysize + 2 * margin;
+
+	VipsImage *base = vips_image_new();
+	VipsImage **t = (VipsImage **) vips_object_local_array(VIPS_OBJECT(base), 13);
+
+	if (
+		vips_colourspace(in, &t[0], interpretation, NULL) ||
+		vips_cast(t[0], &t[1], format, NULL)
+	) {
+		g_object_unref(base);
+		return 1;
+	}
+
+	// The shadow takes the shape of the alpha channel, if any
+	if (has_alpha_channel(t[1]) == 1) {
+		t[2] = t[1];
+		g_object_ref(t[1]);
+	} else if (vips_add_band(t[1], &t[2], max_alpha)) {
+		g_object_unref(base);
+		return 1;
+	}
+
+	if (
+		vips_extract_band(t[2], &t[3], t[2]->Bands - 1, NULL) ||
+		vips_embed(t[3], &t[4], margin + offset_x, margin + offset_y, width, height, NULL)
+	) {
+		g_object_unref(base);
+		return 1;
+	}
+
+	if (sigma > 0) {
+		if (vips_gaussblur_bridge(t[4], &t[5], sigma, 0.2)) {
+			g_object_unref(base);
+			return 1;
+		}
+	} else {
+		t[5] = t[4];
+		g_object_ref(t[4]);
+	}
+
+	if (
+		vips_linear1(t[5], &t[6], opacity, 0.0, NULL) ||
+		vips_black(&t[7], width, height, NULL) ||
+		vips_linear(t[7], &t[8], zeros, colour, 3, NULL) ||
+		vips_bandjoin2(t[8], t[6], &t[9], NULL) ||
+		vips_cast(t[9], &t[10], format, NULL) ||
+		vips_copy(t[10], &t[11], "interpretation", interpretation, NULL) ||
+		vips_embed(t[2], &t[12], margin, margin, width, height, NULL) ||
+		vips_composite2(t[11], t[12], out, VIPS_BLEND_MODE_OVER, NULL)
+	) {
+		g_object_unref(base);
+		return 1;
+	}
+
+	g_object_unref(base);
+	return 0;
+#else
+	vips_error("bimg", "drop shadows require libvips 8.6+");
+	return 1;
+#endif
+}
+
+int
+vips_remove_background_bridge(VipsImage *in, VipsImage **out, double r, double g, double b, double threshold, double feather) {
+	double ones[3] = { 1.0, 1.0, 1.0 };
+	double background[3] = { -r, -g, -b };
+	VipsPel *pel;
+	int x, y, i;
+
+	VipsImage *base = vips_image_new();
+	VipsImage **t = (VipsImage **) vips_object_local_array(VIPS_OBJECT(base), 17);
+
+	if (
+		vips_colourspace(in, &t[0], VIPS_INTERPRETATION_sRGB, NULL) ||
+		vips_cast(t[0], &t[1], VIPS_FORMAT_UCHAR, NULL) ||
+		vips_extract_band(t[1], &t[2], 0, "n", 3, NULL)
+	) {
+		g_object_unref(base);
+		return 1;
+	}
+
+	// Pixels within the threshold of the background colour, as 255
+	if (
+		vips_linear(t[2], &t[3], ones, background, 3, NULL) ||
+		vips_multiply(t[3], t[3], &t[4], NULL) ||
+		vips_bandmean(t[4], &t[5], NULL) ||
+		vips_linear1(t[5], &t[6], 3.0, 0.0, NULL) ||
+		vips_lesseq_const1(t[6], &t[7], threshold * threshold, NULL) ||
+		(t[8] = vips_image_copy_memory(t[7])) == NULL
+	) {
+		g_object_unref(base);
+		return 1;
+	}
+
+	// Flood fill the background pixels connected to the edges
+	for (i = 0; i < 2 * (t[8]->Xsize + t[8]->Ysize); i++) {
+		if (i < t[8]->Xsize) {
+			x = i, y = 0;
+		} else if (i < 2 * t[8]->Xsize) {
+			x = i - t[8]->Xsize, y = t[8]->Ysize - 1;
+		} else if (i < 2 * t[8]->Xsize + t[8]->Ysize) {
+			x = 0, y = i - 2 * t[8]->Xsize;
+		} else {
+			x = t[8]->Xsize - 1, y = i - 2 * t[8]->Xsize - t[8]->Ysize;
+		}
+
+		pel = VIPS_IMAGE_ADDR(t[8], x, y);
+		if (*pel == 255 && vips_draw_flood1(t[8], 128.0, x, y, "equal", TRUE, NULL)) {
+			g_object_unref(base);
+			return 1;
+		}
+	}
+
+	// Opaque everywhere but the flooded background
+	if (vips_notequal_const1(t[8], &t[9], 128.0, NULL)) {
+		g_object_unref(base);
+		return 1;
+	}
+
+	if (feather > 0) {
+		if (vips_gaussblur_bridge(t[9], &t[10], feather, 0.2)) {
+			g_object_unref(base);
+			return 1;
+		}
+	} else {
+		t[10] = t[9];
+		g_object_ref(t[9]);
+	}
+
+	// Combine with the existing alpha channel, if any
+	if (has_alpha_channel(t[1]) == 1) {
+		if (
+			vips_extract_band(t[1], &t[11], t[1]->Bands - 1, NULL) ||
+			vips_multiply(t[10], t[11], &t[12], NULL) ||
+			vips_linear1(t[12], &t[13], 1.0 / 255.0, 0.0, NULL)
+		) {
+			g_object_unref(base);
+			return 1;
+		}
+	} else {
+		t[13] = t[10];
+		g_object_ref(t[10]);
+	}
+
+	if (
+		vips_cast(t[13], &t[14], VIPS_FORMAT_UCHAR, NULL) ||
+		vips_bandjoin2(t[2], t[14], &t[15], NULL) ||
+		vips_copy(t[15], out, "interpretation", VIPS_INTERPRETATION_sRGB, NULL)
+	) {
+		g_object_unref(base);
+		return 1;
+	}
+
+	g_object_unref(base);
+	return 0;
+}
+
+int
+vips_copy_bridge(VipsImage *in, VipsImage **out) {
+	return vips_copy(in, out, NULL);
+}
+
+void
+vips_image_set_blob_bridge(VipsImage *image, const char *name, const void *data, size_t len) {
+	void *copy = g_malloc(len);
+	memcpy(copy, data, len);
+	vips_image_set_blob(image, name, (VipsCallbackFn) g_free, copy, len);
+}
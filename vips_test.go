@@ -246,6 +246,45 @@ func TestVipsExifShort(t *testing.T) {
 	}
 }
 
+func TestSaveInterpretation(t *testing.T) {
+	tt := []struct {
+		source   Interpretation
+		options  vipsSaveOptions
+		expected Interpretation
+	}{
+		{InterpretationRGB16, vipsSaveOptions{Type: PNG, Interpretation: InterpretationSRGB}, InterpretationSRGB},
+		{InterpretationRGB16, vipsSaveOptions{Type: PNG, Interpretation: InterpretationSRGB, KeepInterpretation: true}, InterpretationRGB16},
+		{InterpretationRGB16, vipsSaveOptions{Type: JPEG, Interpretation: InterpretationSRGB, KeepInterpretation: true}, InterpretationSRGB},
+		{InterpretationRGB16, vipsSaveOptions{Type: PNG, Interpretation: InterpretationSRGB, KeepInterpretation: true, BitDepth: 8}, InterpretationSRGB},
+		{InterpretationGREY16, vipsSaveOptions{Type: TIFF, Interpretation: InterpretationSRGB, KeepInterpretation: true}, InterpretationGREY16},
+		{InterpretationBW, vipsSaveOptions{Type: PNG, Interpretation: InterpretationSRGB, KeepInterpretation: true, BitDepth: 16}, InterpretationGREY16},
+		{InterpretationSRGB, vipsSaveOptions{Type: AVIF, Interpretation: InterpretationSRGB, BitDepth: 16}, InterpretationRGB16},
+		{InterpretationSRGB, vipsSaveOptions{Type: WEBP, Interpretation: InterpretationSRGB, BitDepth: 16}, InterpretationSRGB},
+		{InterpretationCMYK, vipsSaveOptions{Type: PNG, Interpretation: InterpretationSRGB, KeepInterpretation: true}, InterpretationSRGB},
+	}
+
+	for _, tc := range tt {
+		got := saveInterpretation(tc.source, tc.options)
+		if got != tc.expected {
+			t.Fatalf("expected: %d; got: %d for %#v", tc.expected, got, tc.options)
+		}
+	}
+}
+
+func TestVipsHasBuiltinICC(t *testing.T) {
+	if !vipsHasBuiltinICC() {
+		if VipsMajorVersion > 8 || VipsMinorVersion >= 8 {
+			t.Skip("Skip test in libvips without lcms")
+		}
+		return
+	}
+
+	// CMYK images without a profile are imported with the built-in one
+	if _, err := BuiltinICCProfile(ICCProfileCMYK); err != nil {
+		t.Fatalf("Cannot load the built-in CMYK profile: %s", err)
+	}
+}
+
 func readImage(file string) []byte {
 	img, _ := os.Open(path.Join("testdata", file))
 	buf, _ := ioutil.ReadAll(img)